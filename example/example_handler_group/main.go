@@ -23,7 +23,7 @@ func main() {
 		return
 	}
 	concretePath := path.Join(runPath, "conf")
-	constructor := constructorbuilder.BuildConstructor(factory, concretePath)
+	constructor := constructorbuilder.MustBuildConstructor(factory, concretePath)
 	mainProcess := "handler_group_a"
 
 	run(constructor, mainProcess)
@@ -29,7 +29,7 @@ func main() {
 		return
 	}
 	concretePath := path.Join(runPath, "conf")
-	constructor := constructorbuilder.BuildConstructor(factory, concretePath)
+	constructor := constructorbuilder.MustBuildConstructor(factory, concretePath)
 	mainProcess := "layer_center"
 
 	run(constructor, mainProcess)
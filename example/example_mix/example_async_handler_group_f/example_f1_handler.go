@@ -2,6 +2,7 @@ package exampleasynchandlergroupf
 
 import (
 	"fmt"
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -12,6 +13,10 @@ type ExampleF1Handler struct {
 	frame.HandlerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleF1Handler]()
+}
+
 func NewExampleF1Handler() *ExampleF1Handler {
 	return &ExampleF1Handler{}
 }
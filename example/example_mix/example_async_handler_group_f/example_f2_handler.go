@@ -2,6 +2,7 @@ package exampleasynchandlergroupf
 
 import (
 	"fmt"
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -11,6 +12,10 @@ type ExampleF2Handler struct {
 	frame.HandlerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleF2Handler]()
+}
+
 func NewExampleF2Handler() *ExampleF2Handler {
 	return &ExampleF2Handler{}
 }
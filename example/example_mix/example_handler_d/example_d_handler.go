@@ -2,6 +2,7 @@ package examplehandlerd
 
 import (
 	"fmt"
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -11,6 +12,10 @@ type ExampleDHandler struct {
 	frame.HandlerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleDHandler]()
+}
+
 func NewExampleDHandler() *ExampleDHandler {
 	return &ExampleDHandler{}
 }
@@ -2,6 +2,7 @@ package examplehandlergroupe
 
 import (
 	"fmt"
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -11,6 +12,10 @@ type ExampleE1Handler struct {
 	frame.HandlerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleE1Handler]()
+}
+
 func NewExampleE1Handler() *ExampleE1Handler {
 	return &ExampleE1Handler{}
 }
@@ -2,6 +2,7 @@ package examplehandlergroupe
 
 import (
 	"fmt"
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -11,6 +12,10 @@ type ExampleE2Handler struct {
 	frame.HandlerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleE2Handler]()
+}
+
 func NewExampleE2Handler() *ExampleE2Handler {
 	return &ExampleE2Handler{}
 }
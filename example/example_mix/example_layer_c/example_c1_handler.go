@@ -2,6 +2,7 @@ package examplelayerc
 
 import (
 	"fmt"
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -11,6 +12,10 @@ type ExampleC1Handler struct {
 	frame.HandlerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleC1Handler]()
+}
+
 func NewExampleC1Handler() *ExampleC1Handler {
 	return &ExampleC1Handler{}
 }
@@ -2,6 +2,7 @@ package examplelayerc
 
 import (
 	"fmt"
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -11,6 +12,10 @@ type ExampleC2Handler struct {
 	frame.HandlerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleC2Handler]()
+}
+
 func NewExampleC2Handler() *ExampleC2Handler {
 	return &ExampleC2Handler{}
 }
@@ -1,6 +1,7 @@
 package examplelayerc
 
 import (
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -10,6 +11,10 @@ type ExampleCDivider struct {
 	frame.DividerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleCDivider]()
+}
+
 func NewExampleCDivider() *ExampleCDivider {
 	return &ExampleCDivider{}
 }
@@ -2,6 +2,7 @@ package examplelayera
 
 import (
 	"fmt"
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -11,6 +12,10 @@ type ExampleA1Handler struct {
 	frame.HandlerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleA1Handler]()
+}
+
 func NewExampleA1Handler() *ExampleA1Handler {
 	return &ExampleA1Handler{}
 }
@@ -2,6 +2,7 @@ package examplelayera
 
 import (
 	"fmt"
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -11,6 +12,10 @@ type ExampleA2Handler struct {
 	frame.HandlerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleA2Handler]()
+}
+
 func NewExampleA2Handler() *ExampleA2Handler {
 	return &ExampleA2Handler{}
 }
@@ -1,6 +1,7 @@
 package examplelayera
 
 import (
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -10,6 +11,10 @@ type ExampleADivider struct {
 	frame.DividerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleADivider]()
+}
+
 func NewExampleADivider() *ExampleADivider {
 	return &ExampleADivider{}
 }
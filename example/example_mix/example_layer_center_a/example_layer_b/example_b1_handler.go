@@ -2,6 +2,7 @@ package examplelayerb
 
 import (
 	"fmt"
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -11,6 +12,10 @@ type ExampleB1Handler struct {
 	frame.HandlerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleB1Handler]()
+}
+
 func NewExampleB1Handler() *ExampleB1Handler {
 	return &ExampleB1Handler{}
 }
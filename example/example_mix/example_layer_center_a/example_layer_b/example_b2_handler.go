@@ -2,6 +2,7 @@ package examplelayerb
 
 import (
 	"fmt"
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -11,6 +12,10 @@ type ExampleB2Handler struct {
 	frame.HandlerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleB2Handler]()
+}
+
 func NewExampleB2Handler() *ExampleB2Handler {
 	return &ExampleB2Handler{}
 }
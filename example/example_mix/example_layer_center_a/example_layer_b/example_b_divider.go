@@ -1,6 +1,7 @@
 package examplelayerb
 
 import (
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -10,6 +11,10 @@ type ExampleBDivider struct {
 	frame.DividerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleBDivider]()
+}
+
 func NewExampleBDivider() *ExampleBDivider {
 	return &ExampleBDivider{}
 }
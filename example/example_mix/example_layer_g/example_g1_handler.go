@@ -2,6 +2,7 @@ package examplelayerb
 
 import (
 	"fmt"
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -11,6 +12,10 @@ type ExampleG1Handler struct {
 	frame.HandlerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleG1Handler]()
+}
+
 func NewExampleG1Handler() *ExampleG1Handler {
 	return &ExampleG1Handler{}
 }
@@ -2,6 +2,7 @@ package examplelayerb
 
 import (
 	"fmt"
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -11,6 +12,10 @@ type ExampleG2Handler struct {
 	frame.HandlerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleG2Handler]()
+}
+
 func NewExampleG2Handler() *ExampleG2Handler {
 	return &ExampleG2Handler{}
 }
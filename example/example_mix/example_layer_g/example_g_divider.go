@@ -1,6 +1,7 @@
 package examplelayerb
 
 import (
+	"ghgroups"
 	"ghgroups/frame"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"reflect"
@@ -10,6 +11,10 @@ type ExampleGDivider struct {
 	frame.DividerBaseInterface
 }
 
+func init() {
+	ghgroups.MustRegister[*ExampleGDivider]()
+}
+
 func NewExampleGDivider() *ExampleGDivider {
 	return &ExampleGDivider{}
 }
@@ -2,15 +2,6 @@ package main
 
 import (
 	"fmt"
-	examplelayera "ghgroups/example/example_mix/example_layer_center_a/example_layer_a"
-	examplelayerb "ghgroups/example/example_mix/example_layer_center_a/example_layer_b"
-
-	exampleasynchandlergroupf "ghgroups/example/example_mix/example_async_handler_group_f"
-	examplehandlerd "ghgroups/example/example_mix/example_handler_d"
-	examplehandlergroupe "ghgroups/example/example_mix/example_handler_group_e"
-	examplelayerc "ghgroups/example/example_mix/example_layer_c"
-
-	examplelayerg "ghgroups/example/example_mix/example_layer_g"
 	"ghgroups/frame"
 	"ghgroups/frame/constructor"
 	constructorbuilder "ghgroups/frame/constructor_builder"
@@ -18,42 +9,26 @@ import (
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"os"
 	"path"
-	"reflect"
+
+	// 各个包在init中把自己的组件注册到默认注册表
+	_ "ghgroups/example/example_mix/example_async_handler_group_f"
+	_ "ghgroups/example/example_mix/example_handler_d"
+	_ "ghgroups/example/example_mix/example_handler_group_e"
+	_ "ghgroups/example/example_mix/example_layer_c"
+	_ "ghgroups/example/example_mix/example_layer_center_a/example_layer_a"
+	_ "ghgroups/example/example_mix/example_layer_center_a/example_layer_b"
+	_ "ghgroups/example/example_mix/example_layer_g"
 )
 
 func main() {
 	factory := factory.NewFactory()
-	factory.Register(reflect.TypeOf(examplelayera.ExampleA1Handler{}))
-	factory.Register(reflect.TypeOf(examplelayera.ExampleA2Handler{}))
-	factory.Register(reflect.TypeOf(examplelayera.ExampleADivider{}))
-
-	factory.Register(reflect.TypeOf(examplelayerb.ExampleB1Handler{}))
-	factory.Register(reflect.TypeOf(examplelayerb.ExampleB2Handler{}))
-	factory.Register(reflect.TypeOf(examplelayerb.ExampleBDivider{}))
-
-	factory.Register(reflect.TypeOf(examplelayerc.ExampleC1Handler{}))
-	factory.Register(reflect.TypeOf(examplelayerc.ExampleC2Handler{}))
-	factory.Register(reflect.TypeOf(examplelayerc.ExampleCDivider{}))
-
-	factory.Register(reflect.TypeOf(examplehandlerd.ExampleDHandler{}))
-
-	factory.Register(reflect.TypeOf(examplehandlergroupe.ExampleE1Handler{}))
-	factory.Register(reflect.TypeOf(examplehandlergroupe.ExampleE2Handler{}))
-
-	factory.Register(reflect.TypeOf(exampleasynchandlergroupf.ExampleF1Handler{}))
-	factory.Register(reflect.TypeOf(exampleasynchandlergroupf.ExampleF2Handler{}))
-
-	factory.Register(reflect.TypeOf(examplelayerg.ExampleGDivider{}))
-	factory.Register(reflect.TypeOf(examplelayerg.ExampleG1Handler{}))
-	factory.Register(reflect.TypeOf(examplelayerg.ExampleG2Handler{}))
-
 	runPath, errGetWd := os.Getwd()
 	if errGetWd != nil {
 		fmt.Printf("%v", errGetWd)
 		return
 	}
 	concretePath := path.Join(runPath, "conf")
-	constructor := constructorbuilder.BuildConstructor(factory, concretePath)
+	constructor := constructorbuilder.MustBuildConstructor(factory, concretePath)
 
 	fmt.Print("\n\nlayer_center_main:\n\n")
 	run(constructor, "layer_center_main")
@@ -0,0 +1,72 @@
+package allocation
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 内置divider运行时更新流量分配时共用的工具
+// Bindings 记录divider被哪些Layer使用以及这些Layer的handler，更新前校验每个目标都存在
+// History 记录每一次生效的分配及其时间，用于审计
+
+type Bindings struct {
+	mutex  sync.RWMutex
+	layers map[string]map[string]struct{}
+}
+
+func (b *Bindings) Bind(layer string, handlers []string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.layers == nil {
+		b.layers = make(map[string]map[string]struct{})
+	}
+	handlerSet := make(map[string]struct{}, len(handlers))
+	for _, handler := range handlers {
+		handlerSet[handler] = struct{}{}
+	}
+	b.layers[layer] = handlerSet
+}
+
+// Check 校验targets都是所有绑定Layer的handler，没有绑定任何Layer时不做校验
+func (b *Bindings) Check(divider string, targets []string) error {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	layers := make([]string, 0, len(b.layers))
+	for layer := range b.layers {
+		layers = append(layers, layer)
+	}
+	sort.Strings(layers)
+	for _, layer := range layers {
+		for _, target := range targets {
+			if _, ok := b.layers[layer][target]; !ok {
+				return fmt.Errorf("divider %s target %s is not in handlers of layer %s", divider, target, layer)
+			}
+		}
+	}
+	return nil
+}
+
+type Change[T any] struct {
+	Time       time.Time
+	Allocation T
+}
+
+type History[T any] struct {
+	mutex   sync.RWMutex
+	changes []Change[T]
+}
+
+func (h *History[T]) Append(allocation T) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.changes = append(h.changes, Change[T]{Time: time.Now(), Allocation: allocation})
+}
+
+// Changes 按时间顺序返回所有分配，第一条是加载配置时的分配
+func (h *History[T]) Changes() []Change[T] {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return append([]Change[T](nil), h.changes...)
+}
@@ -0,0 +1,28 @@
+package allocation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindings(t *testing.T) {
+	var bindings Bindings
+	assert.Nil(t, bindings.Check("divider_a", []string{"handler_z"}))
+
+	bindings.Bind("layer_a", []string{"handler_x", "handler_y"})
+	bindings.Bind("layer_b", []string{"handler_x"})
+	assert.Nil(t, bindings.Check("divider_a", []string{"handler_x"}))
+	assert.EqualError(t, bindings.Check("divider_a", []string{"handler_x", "handler_y"}), "divider divider_a target handler_y is not in handlers of layer layer_b")
+}
+
+func TestHistory(t *testing.T) {
+	var history History[map[string]int]
+	assert.Empty(t, history.Changes())
+	history.Append(map[string]int{"a": 1})
+	history.Append(map[string]int{"a": 2})
+	changes := history.Changes()
+	assert.Len(t, changes, 2)
+	assert.Equal(t, map[string]int{"a": 2}, changes[1].Allocation)
+	assert.False(t, changes[1].Time.Before(changes[0].Time))
+}
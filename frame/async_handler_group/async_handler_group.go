@@ -17,10 +17,27 @@ type HandlerGroupConf struct {
 	Handlers []string `yaml:"handlers"`
 }
 
+func init() {
+	frame.RegisterConfParser("AsyncHandlerGroup", func(configure []byte) error {
+		_, err := ParseConf(configure)
+		return err
+	})
+}
+
+// ParseConf 解析AsyncHandlerGroup的配置，不会构建其中的handler，Constructor在创建之前以同样的方式校验配置
+func ParseConf(configure []byte) (*HandlerGroupConf, error) {
+	conf := new(HandlerGroupConf)
+	if err := yaml.Unmarshal(configure, conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
 type AsyncHandlerGroup struct {
 	HandlerGroupInterface
 	conf                 *HandlerGroupConf
 	handlers             []frame.HandlerBaseInterface
+	handlersName         []string
 	constructorInterface frame.ConstructorInterface
 }
 
@@ -37,6 +54,9 @@ func (a *AsyncHandlerGroup) Handle(context *ghgroupscontext.GhGroupsContext) boo
 	wg := sync.WaitGroup{}
 	checkChan := make(chan bool, len(a.handlers))
 	for _, handler := range a.handlers {
+		if debughelper.SkipDisabled(a.constructorInterface, handler.Name(), context) {
+			continue
+		}
 		wg.Add(1)
 		go func(handler frame.HandlerBaseInterface) {
 			status := debughelper.HandleWithShowDuration(handler, handler.Name(), context)
@@ -69,8 +89,7 @@ func (a *AsyncHandlerGroup) LoadConfigFromFile(confPath string) error {
 }
 
 func (a *AsyncHandlerGroup) LoadConfigFromMemory(configure []byte) error {
-	conf := new(HandlerGroupConf)
-	err := yaml.Unmarshal([]byte(configure), conf)
+	conf, err := ParseConf(configure)
 	if err != nil {
 		return err
 	}
@@ -91,7 +110,7 @@ func (a *AsyncHandlerGroup) initHandlers() error {
 			if handlerInterface, ok := someInterface.(frame.HandlerBaseInterface); !ok {
 				return fmt.Errorf("handler %s is not frame.HandlerBaseInterface", handlerName)
 			} else {
-				err = a.Add(handlerInterface)
+				err = a.add(handlerName, handlerInterface)
 				if err != nil {
 					return err
 				}
@@ -108,10 +127,39 @@ func (a *AsyncHandlerGroup) Name() string {
 }
 
 func (a *AsyncHandlerGroup) Add(handlderInterface frame.HandlerBaseInterface) error {
+	return a.add(handlderInterface.Name(), handlderInterface)
+}
+
+// add 按引用的名称记录handler，Rebind按这个名称而不是handler当前的Name查找
+func (a *AsyncHandlerGroup) add(name string, handlderInterface frame.HandlerBaseInterface) error {
 	a.handlers = append(a.handlers, handlderInterface)
+	a.handlersName = append(a.handlersName, name)
 	return nil
 }
 
+// Concurrent 表示handler并发执行，Constructor据此把AsyncHandlerGroup与HandlerGroup区分开
+func (a *AsyncHandlerGroup) Concurrent() bool {
+	return true
+}
+
+// Rebind 把引用名称为name的handler替换为concrete，供Constructor.Override使用，不能与Handle并发调用
+// 按配置中引用的名称查找，之前Override的组件Name与name不同时也能再次替换
+func (a *AsyncHandlerGroup) Rebind(name string, concrete any) (bool, error) {
+	rebound := false
+	for i, handlerName := range a.handlersName {
+		if handlerName != name {
+			continue
+		}
+		handlerInterface, ok := concrete.(frame.HandlerBaseInterface)
+		if !ok {
+			return false, fmt.Errorf("handler %s is not frame.HandlerBaseInterface", name)
+		}
+		a.handlers[i] = handlerInterface
+		rebound = true
+	}
+	return rebound, nil
+}
+
 func (a *AsyncHandlerGroup) SetConstructorInterface(constructorInterface any) {
 	constructorInterfaceNew, ok := constructorInterface.(frame.ConstructorInterface)
 	if !ok {
@@ -0,0 +1,364 @@
+package banditdivider
+
+import (
+	"encoding/json"
+	"fmt"
+	"ghgroups/frame"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 多臂老虎机divider，在所属Layer的handler之间按在线反馈的收益调整流量
+// 每个handler（臂）维护一个Beta(alpha, beta)后验，初始为Beta(1, 1)，收益reward取值[0, 1]
+// epsilon_greedy以epsilon的概率随机探索，否则选择后验均值最大的臂；thompson对每个臂采样后选择最大的
+// 一个BanditDivider只能被一个Layer使用，点击等反馈通过RecordReward回传，也可以经由Layer或Constructor按layer名称回传
+// 后验保存在只读的快照中，Select不加锁；RecordReward复制快照、更新后原子替换
+// 未配置seed时每个P使用各自的随机数来源，Select不会互相等待
+// seed只用于测试和复现：所有Select共用同一个随机数来源并串行生成随机数，线上不要配置
+// 配置了snapshot时，加载配置时从该文件恢复后验，快照只能恢复到保存它的layer
+// 每snapshot_every次反馈由后台goroutine写回，文件IO不在RecordReward的调用路径上；调用SaveSnapshot或Close时立即写回
+
+const (
+	AlgorithmEpsilonGreedy = "epsilon_greedy"
+	AlgorithmThompson      = "thompson"
+)
+
+const defaultSnapshotEvery = 100
+
+type BanditDividerConf struct {
+	Type          string  `yaml:"type"`
+	Name          string  `yaml:"name"`
+	Algorithm     string  `yaml:"algorithm"`
+	Epsilon       float64 `yaml:"epsilon"`
+	Seed          *int64  `yaml:"seed"`
+	Snapshot      string  `yaml:"snapshot"`
+	SnapshotEvery int     `yaml:"snapshot_every"`
+}
+
+// Arm 是某个handler的后验参数
+type Arm struct {
+	Alpha float64 `json:"alpha"`
+	Beta  float64 `json:"beta"`
+}
+
+func (a Arm) Mean() float64 {
+	return a.Alpha / (a.Alpha + a.Beta)
+}
+
+type snapshot struct {
+	Layer string         `json:"layer"`
+	Arms  map[string]Arm `json:"arms"`
+}
+
+type BanditDivider struct {
+	frame.DividerBaseInterface
+	frame.LoadConfigFromMemoryInterface
+	frame.RewardRecorderInterface
+	conf          BanditDividerConf
+	mutex         sync.Mutex
+	table         atomic.Pointer[armsTable]
+	seeded        *rand.Rand
+	seedMutex     sync.Mutex
+	randPool      sync.Pool
+	restored      map[string]Arm
+	restoredLayer string
+	rewards       int
+	ioMutex       sync.Mutex
+	saveSignal    chan struct{}
+	saveDone      chan struct{}
+	saveErr       error
+	closed        bool
+}
+
+// armsTable 是某一时刻所有臂的后验，发布之后只读
+type armsTable struct {
+	layer string
+	names []string
+	arms  map[string]Arm
+}
+
+func NewBanditDivider() *BanditDivider {
+	return &BanditDivider{}
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// LoadConfigFromMemoryInterface
+func (b *BanditDivider) LoadConfigFromMemory(configure []byte) error {
+	conf := new(BanditDividerConf)
+	err := yaml.Unmarshal([]byte(configure), conf)
+	if err != nil {
+		return err
+	}
+	switch conf.Algorithm {
+	case AlgorithmEpsilonGreedy:
+		if conf.Epsilon < 0 || conf.Epsilon > 1 {
+			return fmt.Errorf("bandit divider %s epsilon %v must be in [0, 1]", conf.Name, conf.Epsilon)
+		}
+	case AlgorithmThompson:
+	default:
+		return fmt.Errorf("bandit divider %s algorithm %s is invalid, must be epsilon_greedy or thompson", conf.Name, conf.Algorithm)
+	}
+	if conf.SnapshotEvery < 0 {
+		return fmt.Errorf("bandit divider %s snapshot_every must not be negative", conf.Name)
+	}
+	if conf.SnapshotEvery == 0 {
+		conf.SnapshotEvery = defaultSnapshotEvery
+	}
+
+	seed := time.Now().UnixNano()
+	if conf.Seed != nil {
+		seed = *conf.Seed
+	}
+
+	var restored snapshot
+	if conf.Snapshot != "" {
+		data, err := os.ReadFile(conf.Snapshot)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, &restored); err != nil {
+				return fmt.Errorf("bandit divider %s snapshot %s is invalid: %v", conf.Name, conf.Snapshot, err)
+			}
+		}
+	}
+
+	b.conf = *conf
+	if conf.Seed != nil {
+		b.seeded = rand.New(rand.NewSource(seed))
+	} else {
+		b.randPool.New = func() any {
+			return rand.New(rand.NewSource(atomic.AddInt64(&seed, 1)))
+		}
+	}
+	b.restored = restored.Arms
+	b.restoredLayer = restored.Layer
+	if conf.Snapshot != "" && b.saveSignal == nil {
+		b.saveSignal = make(chan struct{}, 1)
+		b.saveDone = make(chan struct{})
+		go b.saveLoop()
+	}
+	return nil
+}
+
+// withRand 使用随机数来源，配置了seed时串行使用同一个来源，否则从池中取一个
+func (b *BanditDivider) withRand(f func(r *rand.Rand)) {
+	if b.seeded != nil {
+		b.seedMutex.Lock()
+		defer b.seedMutex.Unlock()
+		f(b.seeded)
+		return
+	}
+	r := b.randPool.Get().(*rand.Rand)
+	f(r)
+	b.randPool.Put(r)
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// ConcreteInterface
+func (b *BanditDivider) Name() string {
+	return b.conf.Name
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// DividerBinderInterface
+func (b *BanditDivider) BindLayer(layer string, handlers []string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	current := b.table.Load()
+	if current != nil && current.layer != layer {
+		return fmt.Errorf("bandit divider %s is already used by layer %s", b.conf.Name, current.layer)
+	}
+	if current == nil && b.restoredLayer != "" && b.restoredLayer != layer {
+		return fmt.Errorf("bandit divider %s snapshot %s belongs to layer %s, not %s", b.conf.Name, b.conf.Snapshot, b.restoredLayer, layer)
+	}
+	// 重新绑定时保留已经学到的后验，只增加新的臂、删除不再存在的臂
+	names := append([]string(nil), handlers...)
+	sort.Strings(names)
+	arms := make(map[string]Arm, len(names))
+	for _, name := range names {
+		if current != nil {
+			if live, ok := current.arms[name]; ok {
+				arms[name] = live
+				continue
+			}
+		}
+		arm := Arm{Alpha: 1, Beta: 1}
+		if restored, ok := b.restored[name]; ok && restored.Alpha > 0 && restored.Beta > 0 {
+			arm = restored
+		}
+		arms[name] = arm
+	}
+	b.table.Store(&armsTable{layer: layer, names: names, arms: arms})
+	return nil
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// DividerBaseInterface
+func (b *BanditDivider) Select(context *ghgroupscontext.GhGroupsContext) string {
+	table := b.table.Load()
+	if table == nil || len(table.names) == 0 {
+		return ""
+	}
+	selected := ""
+	b.withRand(func(r *rand.Rand) {
+		if b.conf.Algorithm == AlgorithmThompson {
+			selected = selectThompson(r, table)
+		} else {
+			selected = b.selectEpsilonGreedy(r, table, context)
+		}
+	})
+	return selected
+}
+
+func (b *BanditDivider) selectEpsilonGreedy(r *rand.Rand, table *armsTable, context *ghgroupscontext.GhGroupsContext) string {
+	if r.Float64() < b.conf.Epsilon {
+		context.Trace(b.conf.Name+".explore", true)
+		return table.names[r.Intn(len(table.names))]
+	}
+	best := table.names[0]
+	for _, name := range table.names[1:] {
+		if table.arms[name].Mean() > table.arms[best].Mean() {
+			best = name
+		}
+	}
+	return best
+}
+
+func selectThompson(r *rand.Rand, table *armsTable) string {
+	best := ""
+	bestSample := -1.0
+	for _, name := range table.names {
+		arm := table.arms[name]
+		sample := sampleBeta(r, arm.Alpha, arm.Beta)
+		if sample > bestSample {
+			best = name
+			bestSample = sample
+		}
+	}
+	return best
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// RewardRecorderInterface
+
+// RecordReward 回传一次曝光的收益，reward取值[0, 1]，点击可以记为1，未点击记为0
+func (b *BanditDivider) RecordReward(layer string, handler string, reward float64) error {
+	if reward < 0 || reward > 1 {
+		return fmt.Errorf("bandit divider %s reward %v must be in [0, 1]", b.conf.Name, reward)
+	}
+	b.mutex.Lock()
+	table := b.table.Load()
+	if table == nil || layer != table.layer {
+		b.mutex.Unlock()
+		return fmt.Errorf("bandit divider %s is not used by layer %s", b.conf.Name, layer)
+	}
+	arm, ok := table.arms[handler]
+	if !ok {
+		b.mutex.Unlock()
+		return fmt.Errorf("bandit divider %s has no handler %s", b.conf.Name, handler)
+	}
+	arm.Alpha += reward
+	arm.Beta += 1 - reward
+	arms := make(map[string]Arm, len(table.arms))
+	for name, exist := range table.arms {
+		arms[name] = exist
+	}
+	arms[handler] = arm
+	b.table.Store(&armsTable{layer: table.layer, names: table.names, arms: arms})
+	b.rewards++
+	if b.saveSignal != nil && !b.closed && b.rewards%b.conf.SnapshotEvery == 0 {
+		// 后台goroutine正在写时合并为一次
+		select {
+		case b.saveSignal <- struct{}{}:
+		default:
+		}
+	}
+	b.mutex.Unlock()
+	return nil
+}
+
+// saveLoop 在后台写回快照，写入失败时记录错误，由Close返回
+func (b *BanditDivider) saveLoop() {
+	defer close(b.saveDone)
+	for range b.saveSignal {
+		err := b.SaveSnapshot()
+		b.mutex.Lock()
+		b.saveErr = err
+		b.mutex.Unlock()
+	}
+}
+
+// Close 停止后台写回并立即写一次快照，返回最后一次写入的错误，Constructor.Close时调用
+func (b *BanditDivider) Close() error {
+	b.mutex.Lock()
+	if b.saveSignal == nil || b.closed {
+		b.mutex.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.saveSignal)
+	b.mutex.Unlock()
+
+	<-b.saveDone
+	if err := b.SaveSnapshot(); err != nil {
+		return err
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.saveErr
+}
+
+// Arms 返回所有handler当前的后验参数
+func (b *BanditDivider) Arms() map[string]Arm {
+	table := b.table.Load()
+	if table == nil {
+		return map[string]Arm{}
+	}
+	arms := make(map[string]Arm, len(table.arms))
+	for name, arm := range table.arms {
+		arms[name] = arm
+	}
+	return arms
+}
+
+// SaveSnapshot 把后验写入配置的snapshot文件，先写临时文件再重命名，避免重启时读到写了一半的文件
+func (b *BanditDivider) SaveSnapshot() error {
+	if b.conf.Snapshot == "" {
+		return fmt.Errorf("bandit divider %s has no snapshot", b.conf.Name)
+	}
+	layer := ""
+	if table := b.table.Load(); table != nil {
+		layer = table.layer
+	}
+	data, err := json.Marshal(snapshot{Layer: layer, Arms: b.Arms()})
+	if err != nil {
+		return err
+	}
+
+	b.ioMutex.Lock()
+	defer b.ioMutex.Unlock()
+	tmp, err := os.CreateTemp(filepath.Dir(b.conf.Snapshot), filepath.Base(b.conf.Snapshot)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), b.conf.Snapshot)
+}
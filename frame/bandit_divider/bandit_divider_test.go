@@ -0,0 +1,218 @@
+package banditdivider
+
+import (
+	"fmt"
+	"ghgroups/frame"
+	"ghgroups/frame/layer"
+	"ghgroups/frame/utils"
+	"os"
+	"path"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+	samplehandler "ghgroups/frame/sample_handler"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newBoundDivider(t *testing.T, conf string) *BanditDivider {
+	divider := NewBanditDivider()
+	assert.Nil(t, divider.LoadConfigFromMemory([]byte(conf)))
+	assert.Nil(t, divider.BindLayer("layer_a", []string{"handler_x", "handler_y", "handler_z"}))
+	return divider
+}
+
+func TestLoadConfigFromMemory(t *testing.T) {
+	cases := map[string]string{
+		"Input=epsilon_greedy":    "",
+		"Input=thompson":          "",
+		"Input=random":            "bandit divider bandit algorithm random is invalid",
+		"Input=epsilon_greedy_2":  "bandit divider bandit epsilon 2 must be in [0, 1]",
+		"Input=thompson_negative": "bandit divider bandit snapshot_every must not be negative",
+	}
+	confs := map[string]string{
+		"Input=epsilon_greedy":    "name: bandit\nalgorithm: epsilon_greedy\nepsilon: 0.1\n",
+		"Input=thompson":          "name: bandit\nalgorithm: thompson\n",
+		"Input=random":            "name: bandit\nalgorithm: random\n",
+		"Input=epsilon_greedy_2":  "name: bandit\nalgorithm: epsilon_greedy\nepsilon: 2\n",
+		"Input=thompson_negative": "name: bandit\nalgorithm: thompson\nsnapshot_every: -1\n",
+	}
+	for name, expected := range cases {
+		expected, conf := expected, confs[name]
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := NewBanditDivider().LoadConfigFromMemory([]byte(conf))
+			if expected == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.ErrorContains(t, err, expected)
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmEpsilonGreedy, AlgorithmThompson} {
+		conf := fmt.Sprintf("name: bandit\nalgorithm: %s\nepsilon: 0.1\nseed: 42\n", algorithm)
+
+		t.Run("Input=deterministic_"+algorithm, func(t *testing.T) {
+			first, second := newBoundDivider(t, conf), newBoundDivider(t, conf)
+			ctx := ghgroupscontext.NewGhGroupsContext(nil)
+			for i := 0; i < 100; i++ {
+				assert.Equal(t, first.Select(ctx), second.Select(ctx))
+			}
+		})
+
+		t.Run("Input=converge_"+algorithm, func(t *testing.T) {
+			divider := newBoundDivider(t, conf)
+			ctx := ghgroupscontext.NewGhGroupsContext(nil)
+			for i := 0; i < 2000; i++ {
+				handler := divider.Select(ctx)
+				reward := 0.0
+				if handler == "handler_y" && i%2 == 0 {
+					reward = 1
+				} else if i%10 == 0 {
+					reward = 1
+				}
+				assert.Nil(t, divider.RecordReward("layer_a", handler, reward))
+			}
+			counts := make(map[string]int)
+			for i := 0; i < 1000; i++ {
+				counts[divider.Select(ctx)]++
+			}
+			assert.Greater(t, counts["handler_y"], 850)
+			assert.Greater(t, divider.Arms()["handler_y"].Mean(), divider.Arms()["handler_x"].Mean())
+		})
+	}
+
+	t.Run("Input=unbound", func(t *testing.T) {
+		divider := NewBanditDivider()
+		assert.Nil(t, divider.LoadConfigFromMemory([]byte("name: bandit\nalgorithm: thompson\n")))
+		assert.Equal(t, "", divider.Select(ghgroupscontext.NewGhGroupsContext(nil)))
+	})
+}
+
+func TestRecordReward(t *testing.T) {
+	divider := newBoundDivider(t, "name: bandit\nalgorithm: thompson\nseed: 1\n")
+	assert.Nil(t, divider.RecordReward("layer_a", "handler_x", 1))
+	assert.Nil(t, divider.RecordReward("layer_a", "handler_x", 0.5))
+	assert.Equal(t, Arm{Alpha: 2.5, Beta: 1.5}, divider.Arms()["handler_x"])
+
+	assert.EqualError(t, divider.RecordReward("layer_b", "handler_x", 1), "bandit divider bandit is not used by layer layer_b")
+	assert.EqualError(t, divider.RecordReward("layer_a", "handler_w", 1), "bandit divider bandit has no handler handler_w")
+	assert.EqualError(t, divider.RecordReward("layer_a", "handler_x", 2), "bandit divider bandit reward 2 must be in [0, 1]")
+	assert.EqualError(t, divider.BindLayer("layer_b", []string{"handler_x"}), "bandit divider bandit is already used by layer layer_a")
+}
+
+func TestRebind(t *testing.T) {
+	divider := newBoundDivider(t, "name: bandit\nalgorithm: thompson\nseed: 1\n")
+	assert.Nil(t, divider.RecordReward("layer_a", "handler_x", 1))
+	assert.Nil(t, divider.RecordReward("layer_a", "handler_z", 0))
+
+	// 重新绑定时保留已经学到的后验，只增删臂
+	assert.Nil(t, divider.BindLayer("layer_a", []string{"handler_x", "handler_y", "handler_w"}))
+	assert.Equal(t, map[string]Arm{
+		"handler_x": {Alpha: 2, Beta: 1},
+		"handler_y": {Alpha: 1, Beta: 1},
+		"handler_w": {Alpha: 1, Beta: 1},
+	}, divider.Arms())
+}
+
+func TestSnapshot(t *testing.T) {
+	snapshotPath := path.Join(t.TempDir(), "bandit.json")
+	conf := fmt.Sprintf("name: bandit\nalgorithm: thompson\nsnapshot: %s\nsnapshot_every: 2\n", snapshotPath)
+
+	divider := newBoundDivider(t, conf)
+	assert.Nil(t, divider.RecordReward("layer_a", "handler_y", 1))
+	assert.NoFileExists(t, snapshotPath)
+	assert.Nil(t, divider.RecordReward("layer_a", "handler_y", 1))
+	// 快照由后台goroutine写回
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(snapshotPath)
+		return err == nil
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, divider.RecordReward("layer_a", "handler_x", 0))
+	assert.Nil(t, divider.Close())
+	assert.Nil(t, divider.RecordReward("layer_a", "handler_x", 0))
+
+	restored := newBoundDivider(t, conf)
+	defer restored.Close()
+	assert.Equal(t, Arm{Alpha: 3, Beta: 1}, restored.Arms()["handler_y"])
+	assert.Equal(t, Arm{Alpha: 1, Beta: 2}, restored.Arms()["handler_x"])
+
+	t.Run("Input=other_layer", func(t *testing.T) {
+		other := NewBanditDivider()
+		assert.Nil(t, other.LoadConfigFromMemory([]byte(conf)))
+		defer other.Close()
+		err := other.BindLayer("layer_b", []string{"handler_x", "handler_y"})
+		assert.ErrorContains(t, err, "snapshot "+snapshotPath+" belongs to layer layer_a, not layer_b")
+		assert.Empty(t, other.Arms())
+	})
+
+	assert.Nil(t, os.WriteFile(snapshotPath, []byte("{"), 0644))
+	err := NewBanditDivider().LoadConfigFromMemory([]byte(conf))
+	assert.ErrorContains(t, err, "snapshot "+snapshotPath+" is invalid")
+}
+
+func TestInLayer(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data", "layer")
+	assert.Nil(t, errGetWd)
+
+	constructor := utils.BuildConstructor(testDataPath)
+	constructor.Register(reflect.TypeOf(BanditDivider{}))
+	constructor.Register(reflect.TypeOf(samplehandler.SampleAutoConstructHandler{}))
+	constructor.Register(reflect.TypeOf(layer.Layer{}))
+
+	err := constructor.CreateConcrete("layer_bandit")
+	assert.Nil(t, err)
+	someInterface, err := constructor.GetConcrete("bandit_divider_layer")
+	assert.Nil(t, err)
+	divider, ok := someInterface.(*BanditDivider)
+	assert.True(t, ok)
+	assert.Len(t, divider.Arms(), 2)
+
+	someInterface, err = constructor.GetConcrete("layer_bandit")
+	assert.Nil(t, err)
+	layerInterface, ok := someInterface.(frame.LayerBaseInterface)
+	assert.True(t, ok)
+	ctx := ghgroupscontext.NewGhGroupsContext(nil)
+	assert.True(t, layerInterface.Handle(ctx))
+	exposures := ctx.Exposures()
+	assert.Len(t, exposures, 1)
+	assert.Nil(t, divider.RecordReward("layer_bandit", exposures[0].Handler, 1))
+
+	// 不需要知道divider的具体类型，按layer名称回传
+	assert.Nil(t, constructor.RecordReward("layer_bandit", exposures[0].Handler, 1))
+	assert.Equal(t, 3.0, divider.Arms()[exposures[0].Handler].Alpha)
+	assert.ErrorContains(t, constructor.RecordReward("layer_bandit", "handler_unknown", 1), "has no handler handler_unknown")
+}
+
+func TestConcurrentSelect(t *testing.T) {
+	divider := newBoundDivider(t, "name: bandit\nalgorithm: thompson\n")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := ghgroupscontext.NewGhGroupsContext(nil)
+			for j := 0; j < 200; j++ {
+				handler := divider.Select(ctx)
+				assert.NotEmpty(t, handler)
+				if i%2 == 0 {
+					assert.Nil(t, divider.RecordReward("layer_a", handler, 1))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0.0
+	for _, arm := range divider.Arms() {
+		total += arm.Alpha + arm.Beta - 2
+	}
+	assert.Equal(t, 800.0, total)
+}
@@ -0,0 +1,34 @@
+package banditdivider
+
+import (
+	"math"
+	"math/rand"
+)
+
+// sampleBeta 通过两个Gamma分布采样得到Beta(alpha, beta)的样本
+func sampleBeta(r *rand.Rand, alpha float64, beta float64) float64 {
+	x := sampleGamma(r, alpha)
+	y := sampleGamma(r, beta)
+	return x / (x + y)
+}
+
+// sampleGamma 使用Marsaglia-Tsang方法采样Gamma(shape, 1)，shape小于1时通过shape+1的样本变换得到
+func sampleGamma(r *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		return sampleGamma(r, shape+1) * math.Pow(r.Float64(), 1/shape)
+	}
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := r.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := r.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package bucket
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+)
+
+// 基于流量单元id（如用户id、设备id）的哈希分桶
+// 同一个单元id在相同salt下总是落在同一个桶中，不同salt之间的分桶结果相互独立
+// 这使得使用不同salt的Layer之间的流量是正交的
+
+const DefaultBuckets uint32 = 1000
+
+func Bucket(unitID string, salt string, buckets uint32) uint32 {
+	hash := fnv.New64a()
+	hash.Write([]byte(salt))
+	hash.Write([]byte{'.'})
+	hash.Write([]byte(unitID))
+	return uint32(hash.Sum64() % uint64(buckets))
+}
+
+// UnitID 从GhGroupsContext的属性中读取流量单元id
+func UnitID(context *ghgroupscontext.GhGroupsContext, attribute string) (string, bool) {
+	value, ok := context.GetAttribute(attribute)
+	if !ok || value == nil {
+		return "", false
+	}
+	unitID := fmt.Sprint(value)
+	return unitID, unitID != ""
+}
+
+// Range 是左闭右开的桶区间，在yaml中写作[from, to]
+type Range []uint32
+
+func (r Range) From() uint32 {
+	return r[0]
+}
+
+func (r Range) To() uint32 {
+	return r[1]
+}
+
+func (r Range) Contains(bucket uint32) bool {
+	return bucket >= r[0] && bucket < r[1]
+}
+
+func (r Range) Check(buckets uint32) error {
+	if len(r) != 2 {
+		return fmt.Errorf("range %v must be [from, to]", []uint32(r))
+	}
+	if r[0] >= r[1] {
+		return fmt.Errorf("range [%d, %d) is empty", r[0], r[1])
+	}
+	if r[1] > buckets {
+		return fmt.Errorf("range [%d, %d) exceeds buckets %d", r[0], r[1], buckets)
+	}
+	return nil
+}
+
+type NamedRange struct {
+	Name  string
+	Range Range
+}
+
+// CheckOverlap 校验每个区间都合法，且区间之间没有交叉
+func CheckOverlap(ranges []NamedRange, buckets uint32) error {
+	sorted := make([]NamedRange, len(ranges))
+	copy(sorted, ranges)
+	for _, namedRange := range sorted {
+		if err := namedRange.Range.Check(buckets); err != nil {
+			return fmt.Errorf("%s: %v", namedRange.Name, err)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Range.From() < sorted[j].Range.From()
+	})
+	for i := 1; i < len(sorted); i++ {
+		previous, current := sorted[i-1], sorted[i]
+		if current.Range.From() < previous.Range.To() {
+			return fmt.Errorf("%s [%d, %d) overlaps with %s [%d, %d)", current.Name, current.Range.From(), current.Range.To(), previous.Name, previous.Range.From(), previous.Range.To())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package bucket
+
+import (
+	"fmt"
+	"testing"
+
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucket(t *testing.T) {
+	t.Run("Input=Stable", func(t *testing.T) {
+		assert.Equal(t, Bucket("user_1", "layer_a", DefaultBuckets), Bucket("user_1", "layer_a", DefaultBuckets))
+		assert.Less(t, Bucket("user_1", "layer_a", 10), uint32(10))
+	})
+
+	t.Run("Input=Orthogonal", func(t *testing.T) {
+		const total = 20000
+		inA, inB, inBoth := 0, 0, 0
+		for i := 0; i < total; i++ {
+			unitID := fmt.Sprint("user_", i)
+			a := Bucket(unitID, "layer_a", DefaultBuckets) < 500
+			b := Bucket(unitID, "layer_b", DefaultBuckets) < 500
+			if a {
+				inA++
+			}
+			if b {
+				inB++
+			}
+			if a && b {
+				inBoth++
+			}
+		}
+		assert.InDelta(t, 0.5, float64(inA)/total, 0.02)
+		assert.InDelta(t, 0.5, float64(inB)/total, 0.02)
+		assert.InDelta(t, 0.25, float64(inBoth)/total, 0.02)
+	})
+}
+
+func TestUnitID(t *testing.T) {
+	ctx := ghgroupscontext.NewGhGroupsContext(nil)
+	_, ok := UnitID(ctx, "user_id")
+	assert.False(t, ok)
+	ctx.SetAttribute("user_id", 42)
+	unitID, ok := UnitID(ctx, "user_id")
+	assert.True(t, ok)
+	assert.Equal(t, "42", unitID)
+}
+
+func TestCheckOverlap(t *testing.T) {
+	cases := map[string]struct {
+		ranges   []NamedRange
+		expected string
+	}{
+		"Input=Valid": {[]NamedRange{{"a", Range{0, 500}}, {"b", Range{500, 1000}}}, ""},
+		"Input=Gap":   {[]NamedRange{{"a", Range{0, 100}}, {"b", Range{500, 1000}}}, ""},
+		"Input=Overlap": {
+			[]NamedRange{{"a", Range{0, 500}}, {"b", Range{400, 1000}}},
+			"b [400, 1000) overlaps with a [0, 500)",
+		},
+		"Input=Empty":    {[]NamedRange{{"a", Range{500, 500}}}, "a: range [500, 500) is empty"},
+		"Input=Exceeds":  {[]NamedRange{{"a", Range{0, 1001}}}, "a: range [0, 1001) exceeds buckets 1000"},
+		"Input=BadRange": {[]NamedRange{{"a", Range{0}}}, "a: range [0] must be [from, to]"},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			err := CheckOverlap(c.ranges, DefaultBuckets)
+			if c.expected == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.EqualError(t, err, c.expected)
+			}
+		})
+	}
+}
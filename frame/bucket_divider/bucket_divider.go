@@ -0,0 +1,183 @@
+package bucketdivider
+
+import (
+	"fmt"
+	"ghgroups/frame"
+	"ghgroups/frame/allocation"
+	"ghgroups/frame/bucket"
+	"sync"
+	"sync/atomic"
+
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 按流量单元id哈希分桶选择handler的divider，同一个单元id总是选择同一个handler
+// salt默认使用divider名称，同一个实验域中的各个Layer使用不同的salt，彼此之间的流量正交
+// 单元id不存在或者所在的桶不属于任何区间时选择default
+// UpdateRanges 在运行时校验并原子替换所有区间，每次生效的区间都记录在History中
+
+type BucketDividerConf struct {
+	Type    string            `yaml:"type"`
+	Name    string            `yaml:"name"`
+	UnitID  string            `yaml:"unit_id"`
+	Salt    string            `yaml:"salt"`
+	Buckets uint32            `yaml:"buckets"`
+	Default string            `yaml:"default"`
+	Ranges  []BucketRangeConf `yaml:"ranges"`
+}
+
+type BucketRangeConf struct {
+	Handler string       `yaml:"handler"`
+	Range   bucket.Range `yaml:"range"`
+}
+
+type BucketDivider struct {
+	frame.DividerBaseInterface
+	frame.LoadConfigFromMemoryInterface
+	conf        BucketDividerConf
+	ranges      atomic.Pointer[[]BucketRangeConf]
+	updateMutex sync.Mutex
+	bindings    allocation.Bindings
+	history     allocation.History[[]BucketRangeConf]
+}
+
+func NewBucketDivider() *BucketDivider {
+	return &BucketDivider{}
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// LoadConfigFromMemoryInterface
+func (b *BucketDivider) LoadConfigFromMemory(configure []byte) error {
+	conf := new(BucketDividerConf)
+	err := yaml.Unmarshal([]byte(configure), conf)
+	if err != nil {
+		return err
+	}
+	if conf.UnitID == "" {
+		return fmt.Errorf("bucket divider %s must have unit_id", conf.Name)
+	}
+	if conf.Default == "" {
+		return fmt.Errorf("bucket divider %s must have default", conf.Name)
+	}
+	if conf.Salt == "" {
+		conf.Salt = conf.Name
+	}
+	if conf.Buckets == 0 {
+		conf.Buckets = bucket.DefaultBuckets
+	}
+
+	ranges, err := checkRanges(conf.Name, conf.Ranges, conf.Buckets)
+	if err != nil {
+		return err
+	}
+
+	b.conf = *conf
+	b.ranges.Store(&ranges)
+	b.history.Append(ranges)
+	return nil
+}
+
+// checkRanges 校验区间并返回一份拷贝，避免调用方之后修改生效中的区间
+func checkRanges(dividerName string, ranges []BucketRangeConf, buckets uint32) ([]BucketRangeConf, error) {
+	namedRanges := make([]bucket.NamedRange, 0, len(ranges))
+	for _, rangeConf := range ranges {
+		if rangeConf.Handler == "" {
+			return nil, fmt.Errorf("bucket divider %s has range without handler", dividerName)
+		}
+		namedRanges = append(namedRanges, bucket.NamedRange{Name: rangeConf.Handler, Range: rangeConf.Range})
+	}
+	if err := bucket.CheckOverlap(namedRanges, buckets); err != nil {
+		return nil, fmt.Errorf("bucket divider %s: %v", dividerName, err)
+	}
+	copied := make([]BucketRangeConf, 0, len(ranges))
+	for _, rangeConf := range ranges {
+		copied = append(copied, BucketRangeConf{Handler: rangeConf.Handler, Range: append(bucket.Range(nil), rangeConf.Range...)})
+	}
+	return copied, nil
+}
+
+// UpdateRanges 校验后原子替换区间，区间的handler必须存在于所有使用该divider的Layer中
+func (b *BucketDivider) UpdateRanges(ranges []BucketRangeConf) error {
+	b.updateMutex.Lock()
+	defer b.updateMutex.Unlock()
+	ranges, err := checkRanges(b.conf.Name, ranges, b.conf.Buckets)
+	if err != nil {
+		return err
+	}
+	if err := b.bindings.Check(b.conf.Name, rangeOutputs(b.conf.Default, ranges)); err != nil {
+		return err
+	}
+	b.ranges.Store(&ranges)
+	b.history.Append(ranges)
+	return nil
+}
+
+// Ranges 返回当前生效的区间
+func (b *BucketDivider) Ranges() []BucketRangeConf {
+	return append([]BucketRangeConf(nil), b.currentRanges()...)
+}
+
+// History 返回加载配置以及每次UpdateRanges生效的区间和时间
+func (b *BucketDivider) History() []allocation.Change[[]BucketRangeConf] {
+	return b.history.Changes()
+}
+
+func (b *BucketDivider) currentRanges() []BucketRangeConf {
+	ranges := b.ranges.Load()
+	if ranges == nil {
+		return nil
+	}
+	return *ranges
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// ConcreteInterface
+func (b *BucketDivider) Name() string {
+	return b.conf.Name
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// DividerBaseInterface
+func (b *BucketDivider) Select(context *ghgroupscontext.GhGroupsContext) string {
+	unitID, ok := bucket.UnitID(context, b.conf.UnitID)
+	if !ok {
+		return b.conf.Default
+	}
+	index := bucket.Bucket(unitID, b.conf.Salt, b.conf.Buckets)
+	context.Trace(b.conf.Name+".bucket", index)
+	for _, rangeConf := range b.currentRanges() {
+		if rangeConf.Range.Contains(index) {
+			return rangeConf.Handler
+		}
+	}
+	return b.conf.Default
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// SaltInterface
+func (b *BucketDivider) Salt() string {
+	return b.conf.Salt
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// DividerOutputsInterface
+func (b *BucketDivider) Outputs() []string {
+	return rangeOutputs(b.conf.Default, b.currentRanges())
+}
+
+func rangeOutputs(defaultHandler string, ranges []BucketRangeConf) []string {
+	outputs := []string{defaultHandler}
+	for _, rangeConf := range ranges {
+		outputs = append(outputs, rangeConf.Handler)
+	}
+	return outputs
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// DividerBinderInterface
+func (b *BucketDivider) BindLayer(layer string, handlers []string) error {
+	b.bindings.Bind(layer, handlers)
+	return nil
+}
@@ -0,0 +1,122 @@
+package bucketdivider
+
+import (
+	"fmt"
+	"ghgroups/frame/bucket"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfigFromMemory(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data")
+	assert.Nil(t, errGetWd)
+
+	cases := map[string]string{
+		"Input=valid/bucket_divider_a.yaml": "",
+		"Input=invalid/no_unit_id.yaml":     "bucket divider no_unit_id must have unit_id",
+		"Input=invalid/no_default.yaml":     "bucket divider no_default must have default",
+		"Input=invalid/overlap.yaml":        "handler_treatment_b [50, 150) overlaps with handler_treatment_a [0, 100)",
+		"Input=invalid/exceeds.yaml":        "handler_treatment_a: range [90, 110) exceeds buckets 100",
+	}
+	for name, expected := range cases {
+		expected := expected
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			testName := t.Name()
+			comma := strings.Index(testName, "=")
+			assert.Greater(t, comma, 0)
+			confName := testName[comma+1:]
+			confPath := path.Join(testDataPath, confName)
+			data, err := os.ReadFile(confPath)
+			assert.Nil(t, err)
+
+			divider := NewBucketDivider()
+			err = divider.LoadConfigFromMemory(data)
+			if expected == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.ErrorContains(t, err, expected)
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data")
+	assert.Nil(t, errGetWd)
+
+	data, err := os.ReadFile(path.Join(testDataPath, "valid", "bucket_divider_a.yaml"))
+	assert.Nil(t, err)
+	divider := NewBucketDivider()
+	assert.Nil(t, divider.LoadConfigFromMemory(data))
+	assert.Equal(t, []string{"handler_control", "handler_treatment_a", "handler_treatment_b"}, divider.Outputs())
+
+	t.Run("Input=NoUnitID", func(t *testing.T) {
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		assert.Equal(t, "handler_control", divider.Select(ctx))
+	})
+
+	t.Run("Input=Sticky", func(t *testing.T) {
+		counts := make(map[string]int)
+		for i := 0; i < 10000; i++ {
+			unitID := fmt.Sprint("user_", i)
+			ctx := ghgroupscontext.NewGhGroupsContext(nil)
+			ctx.EnableTrace()
+			ctx.SetAttribute("user_id", unitID)
+			selected := divider.Select(ctx)
+			assert.Equal(t, selected, divider.Select(ctx))
+			counts[selected]++
+
+			index, ok := ctx.TraceRoot().Get("bucket_divider_a.bucket")
+			assert.True(t, ok)
+			assert.Equal(t, bucket.Bucket(unitID, "bucket_divider_a", 100), index)
+		}
+		assert.InDelta(t, 1000, counts["handler_treatment_a"], 150)
+		assert.InDelta(t, 1000, counts["handler_treatment_b"], 150)
+		assert.InDelta(t, 8000, counts["handler_control"], 300)
+	})
+}
+
+func TestUpdateRanges(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data")
+	assert.Nil(t, errGetWd)
+
+	data, err := os.ReadFile(path.Join(testDataPath, "valid", "bucket_divider_a.yaml"))
+	assert.Nil(t, err)
+	divider := NewBucketDivider()
+	assert.Nil(t, divider.LoadConfigFromMemory(data))
+	divider.BindLayer("layer_a", []string{"handler_control", "handler_treatment_a", "handler_treatment_b"})
+
+	t.Run("Input=invalid", func(t *testing.T) {
+		err := divider.UpdateRanges([]BucketRangeConf{{Handler: "handler_treatment_a", Range: bucket.Range{0, 60}}, {Handler: "handler_treatment_b", Range: bucket.Range{50, 100}}})
+		assert.ErrorContains(t, err, "handler_treatment_b [50, 100) overlaps with handler_treatment_a [0, 60)")
+		err = divider.UpdateRanges([]BucketRangeConf{{Handler: "handler_treatment_c", Range: bucket.Range{0, 50}}})
+		assert.EqualError(t, err, "divider bucket_divider_a target handler_treatment_c is not in handlers of layer layer_a")
+		assert.Len(t, divider.History(), 1)
+	})
+
+	t.Run("Input=valid", func(t *testing.T) {
+		ranges := []BucketRangeConf{{Handler: "handler_treatment_a", Range: bucket.Range{0, 100}}}
+		assert.Nil(t, divider.UpdateRanges(ranges))
+		ranges[0].Handler = "handler_treatment_b"
+
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		ctx.SetAttribute("user_id", "user_1")
+		assert.Equal(t, "handler_treatment_a", divider.Select(ctx))
+		assert.Equal(t, []string{"handler_control", "handler_treatment_a"}, divider.Outputs())
+
+		history := divider.History()
+		assert.Len(t, history, 2)
+		assert.Len(t, history[0].Allocation, 2)
+		assert.Equal(t, []BucketRangeConf{{Handler: "handler_treatment_a", Range: bucket.Range{0, 100}}}, history[1].Allocation)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path"
 	"reflect"
+	"sort"
 
 	folder "git-codecommit.us-east-1.amazonaws.com/v1/repos/go-utils.git/folder"
 
@@ -37,15 +38,17 @@ func (a *AsyncHandlerGroupConstructor) CreateAsyncHandlerGroupWithConfPath(confF
 		return errGethandlerName
 	}
 	if path, ok := a.handlersConfPath[handlerName]; ok {
-		return fmt.Errorf("handler name %s already exists,path is %s", handlerName, path)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: handlerName, Path: path, Err: fmt.Errorf("handler name %s already exists,path is %s", handlerName, path)}
 	}
 	a.handlersConfPath[handlerName] = confFilePath
 	if a.existAsyncHandlerGroup(handlerName) {
-		return fmt.Errorf("handler %s already exists", handlerName)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: handlerName, Path: confFilePath, Err: fmt.Errorf("handler %s already exists", handlerName)}
 	}
 
 	handlerInterface, err := a.constructAsyncHandlerGroupFromName(handlerName)
 	if err != nil {
+		// 构建失败时撤销登记的配置文件路径，之后可以按同一个配置文件重新构建
+		delete(a.handlersConfPath, handlerName)
 		return err
 	}
 	err = a.RegisterAsyncHandlerGroup(handlerName, handlerInterface)
@@ -67,14 +70,19 @@ func (a *AsyncHandlerGroupConstructor) ParseAsyncHandlerGroupConfFolder(confFold
 			return errGethandlerName
 		}
 		if path, ok := a.handlersConfPath[handlerName]; ok {
-			return fmt.Errorf("handler name %s already exists,path is %s", handlerName, path)
+			return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: handlerName, Path: path, Err: fmt.Errorf("handler name %s already exists,path is %s", handlerName, path)}
 		}
 		a.handlersConfPath[handlerName] = f
 	}
 
-	for handlerName := range a.handlersConfPath {
+	names := make([]string, 0, len(a.handlersConfPath))
+	for name := range a.handlersConfPath {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, handlerName := range names {
 		if a.existAsyncHandlerGroup(handlerName) {
-			return fmt.Errorf("handler %s already exists", handlerName)
+			return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: handlerName, Path: a.handlersConfPath[handlerName], Err: fmt.Errorf("handler %s already exists", handlerName)}
 		}
 
 		handlerInterface, err := a.constructAsyncHandlerGroupFromName(handlerName)
@@ -108,7 +116,7 @@ func (a *AsyncHandlerGroupConstructor) GetAsyncHandlerGroup(name string) (frame.
 
 func (a *AsyncHandlerGroupConstructor) RegisterAsyncHandlerGroup(name string, handler_interface frame.AsyncHandlerGroupBaseInterface) error {
 	if a.existAsyncHandlerGroup(name) {
-		return fmt.Errorf("handler %s is exist", name)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: name, Path: a.handlersConfPath[name], Err: fmt.Errorf("handler %s is exist", name)}
 	}
 	a.handlers[name] = handler_interface
 	return nil
@@ -119,15 +127,15 @@ func (a *AsyncHandlerGroupConstructor) RegisterAsyncHandlerGroup(name string, ha
 func (a *AsyncHandlerGroupConstructor) constructAsyncHandlerGroupFromName(handlerName string) (frame.AsyncHandlerGroupBaseInterface, error) {
 	handlerConfPath, ok := a.handlersConfPath[handlerName]
 	if !ok {
-		return nil, fmt.Errorf("%s: handler's configuration is not set", handlerName)
+		return nil, &frame.BuildError{Kind: frame.ErrNotFound, Name: handlerName, Err: fmt.Errorf("%s: handler's configuration is not set", handlerName)}
 	}
 	handlerInterface, err := a.constructAsyncHandlerGroupFromFile(handlerConfPath)
 	if err != nil {
-		return nil, err
+		return nil, frame.WithBuildInfo(err, handlerName, handlerConfPath)
 	}
 
 	if handlerInterface.Name() != handlerName {
-		return nil, fmt.Errorf("handler Name (%s) mismatch with configuration file Name (%s) path (%s)", handlerInterface.Name(), handlerName, handlerConfPath)
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Type: fmt.Sprintf("%T", handlerInterface), Name: handlerName, Path: handlerConfPath, Err: fmt.Errorf("handler Name (%s) mismatch with configuration file Name (%s) path (%s)", handlerInterface.Name(), handlerName, handlerConfPath)}
 	}
 	return handlerInterface, err
 }
@@ -135,12 +143,12 @@ func (a *AsyncHandlerGroupConstructor) constructAsyncHandlerGroupFromName(handle
 func (a *AsyncHandlerGroupConstructor) constructAsyncHandlerGroupFromFile(filePath string) (frame.AsyncHandlerGroupBaseInterface, error) {
 	configure, errReadFile := os.ReadFile(filePath)
 	if errReadFile != nil {
-		return nil, errReadFile
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: errReadFile}
 	}
 	handlerConf := make(map[any]any)
 	errReadFile = yaml.Unmarshal([]byte(configure), &handlerConf)
 	if errReadFile != nil {
-		return nil, errReadFile
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: errReadFile}
 	}
 	return a.constructAsyncHandlerGroup(handlerConf)
 }
@@ -158,23 +166,23 @@ func (a *AsyncHandlerGroupConstructor) constructAsyncHandlerGroup(handler_conf m
 				}
 				handlerInterface, ok := concrete.(frame.AsyncHandlerGroupBaseInterface)
 				if !ok {
-					return nil, fmt.Errorf("convert %s to AsyncHandlerGroupBaseInterface error", typeName)
+					return nil, &frame.BuildError{Kind: frame.ErrNotConcrete, Type: typeName, Err: fmt.Errorf("convert %s to AsyncHandlerGroupBaseInterface error", typeName)}
 				}
 
 				return handlerInterface, nil
 			}
 		}
 	}
-	return nil, fmt.Errorf("handler configuration must have type %v", handler_conf)
+	return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Err: fmt.Errorf("handler configuration must have type %v", handler_conf)}
 }
 
 func (a *AsyncHandlerGroupConstructor) getAsyncHandlerGroupName(filePath string) (string, error) {
 	s, err := os.Stat(filePath)
 	if err != nil {
-		return "", err
+		return "", &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: err}
 	}
 	if s.IsDir() {
-		return "", fmt.Errorf("file path %s is not a directory", filePath)
+		return "", &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: fmt.Errorf("file path %s is not a directory", filePath)}
 	}
 
 	fileNameWithExt := path.Base(filePath)
@@ -188,6 +196,18 @@ func (a *AsyncHandlerGroupConstructor) existAsyncHandlerGroup(name string) bool
 	return exist
 }
 
+// Exist 判断组件是否已经构建，不会按配置创建
+func (a *AsyncHandlerGroupConstructor) Exist(name string) bool {
+	return a.existAsyncHandlerGroup(name)
+}
+
+// Unregister 删除已经构建的async handler group，之后再次获取时按配置重新构建
+func (a *AsyncHandlerGroupConstructor) Unregister(name string) bool {
+	_, exist := a.handlers[name]
+	delete(a.handlers, name)
+	return exist
+}
+
 func (a *AsyncHandlerGroupConstructor) LoadConfigFromFile(confPath string) error {
 	return nil
 }
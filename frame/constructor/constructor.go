@@ -1,11 +1,15 @@
 package constructor
 
 import (
+	"errors"
 	"fmt"
 	"ghgroups/frame"
+	"io"
 	"os"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	concreteconfmanager "ghgroups/frame/concrete_conf_manager"
 
@@ -24,124 +28,143 @@ type Constructor struct {
 	factoryInterface                      frame.FactoryInterface
 	concreteConfManager                   *concreteconfmanager.ConcreteConfManager
 	deepth                                int
+	debugOverrides                        atomic.Bool
+	mutex                                 sync.Mutex
+	resourceMutex                         sync.Mutex
+	resources                             map[string]any
+	resourceNames                         []string
+	closers                               []io.Closer
+	built                                 []any
+	overrides                             map[string]any
+	references                            map[string]any
+	buildStack                            []string
+	dependents                            map[string][]string
+	kinds                                 []*kindEntry
+	customKinds                           int
 }
 
-func NewConstructor(factory frame.FactoryInterface, confPath string) *Constructor {
-	constructor := &Constructor{}
+// NewConstructor 创建Constructor并解析confPath下的所有配置文件，失败时返回错误
+func NewConstructor(factory frame.FactoryInterface, confPath string) (*Constructor, error) {
+	constructor := &Constructor{
+		resources:  make(map[string]any),
+		overrides:  make(map[string]any),
+		references: make(map[string]any),
+		dependents: make(map[string][]string),
+	}
 
 	// 必须使用反射去做，否则有可能会出现循环依赖
 	//////////////////////////////////////////////////////////////////////////////////////////////////////
 	layerConstructor, err := factory.Create("LayerConstructor", []byte{}, constructor)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	layerConcreteInterface, ok := layerConstructor.(frame.LoadEnvironmentConfInterface)
 	if !ok {
-		panic("layerConstructor is not frame.LoadEnvironmentConfInterface")
+		return nil, fmt.Errorf("layerConstructor is not frame.LoadEnvironmentConfInterface")
 	}
 	err = layerConcreteInterface.LoadEnvironmentConf()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	layerConstructorInterface, ok := layerConstructor.(frame.LayerConstructorInterface)
 	if !ok {
-		panic("layerConstructor is not frame.LayerConstructorInterface")
+		return nil, fmt.Errorf("layerConstructor is not frame.LayerConstructorInterface")
 	}
 
 	//////////////////////////////////////////////////////////////////////////////////////////////////////
 	dividerConstructor, err := factory.Create("DividerConstructor", []byte{}, constructor)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	dividerConcreteInterface, ok := dividerConstructor.(frame.LoadEnvironmentConfInterface)
 	if !ok {
-		panic("dividerConstructor is not frame.LoadEnvironmentConfInterface")
+		return nil, fmt.Errorf("dividerConstructor is not frame.LoadEnvironmentConfInterface")
 	}
 
 	err = dividerConcreteInterface.LoadEnvironmentConf()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	dividerConstructorInterface, ok := dividerConstructor.(frame.DividerConstructorInterface)
 	if !ok {
-		panic("dividerConstructor is not frame.DividerConstructorInterface")
+		return nil, fmt.Errorf("dividerConstructor is not frame.DividerConstructorInterface")
 	}
 	//////////////////////////////////////////////////////////////////////////////////////////////////////
 	handlerConstructor, err := factory.Create("HandlerConstructor", []byte{}, constructor)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	handlerConcreteInterface, ok := handlerConstructor.(frame.LoadEnvironmentConfInterface)
 	if !ok {
-		panic("handlerConstructor is not frame.LoadEnvironmentConfInterface")
+		return nil, fmt.Errorf("handlerConstructor is not frame.LoadEnvironmentConfInterface")
 	}
 	err = handlerConcreteInterface.LoadEnvironmentConf()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	handlerConstructorInterface, ok := handlerConstructor.(frame.HandlerConstructorInterface)
 	if !ok {
-		panic("handlerConstructor is not frame.HandlerConstructorInterface")
+		return nil, fmt.Errorf("handlerConstructor is not frame.HandlerConstructorInterface")
 	}
 	//////////////////////////////////////////////////////////////////////////////////////////////////////
 	layerCenterConstructor, err := factory.Create("LayerCenterConstructor", []byte{}, constructor)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	layerCenterConcreteInterface, ok := layerCenterConstructor.(frame.LoadEnvironmentConfInterface)
 	if !ok {
-		panic("layerCenterConstructor is not frame.LoadEnvironmentConfInterface")
+		return nil, fmt.Errorf("layerCenterConstructor is not frame.LoadEnvironmentConfInterface")
 	}
 	err = layerCenterConcreteInterface.LoadEnvironmentConf()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	layerCenterConstructorInterface, ok := layerCenterConstructor.(frame.LayerCenterConstructorInterface)
 	if !ok {
-		panic("layerCenterConstructor is not frame.LayerCenterConstructorInterface")
+		return nil, fmt.Errorf("layerCenterConstructor is not frame.LayerCenterConstructorInterface")
 	}
 	//////////////////////////////////////////////////////////////////////////////////////////////////////
 	handlerGroupConstructor, err := factory.Create("HandlerGroupConstructor", []byte{}, constructor)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	handlerGroupConcreteInterface, ok := handlerGroupConstructor.(frame.LoadEnvironmentConfInterface)
 	if !ok {
-		panic("handlerGroupConstructor is not frame.LoadEnvironmentConfInterface")
+		return nil, fmt.Errorf("handlerGroupConstructor is not frame.LoadEnvironmentConfInterface")
 	}
 	err = handlerGroupConcreteInterface.LoadEnvironmentConf()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	handlerGroupConstructorInterface, ok := handlerGroupConstructor.(frame.HandlerGroupConstructorInterface)
 	if !ok {
-		panic("handlerGroupConstructor is not frame.HandlerGroupConstructorInterface")
+		return nil, fmt.Errorf("handlerGroupConstructor is not frame.HandlerGroupConstructorInterface")
 	}
 	//////////////////////////////////////////////////////////////////////////////////////////////////////、
 	asyncHandlerGroupConstructor, err := factory.Create("AsyncHandlerGroupConstructor", []byte{}, constructor)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	asyncHandlerGroupConcreteInterface, ok := asyncHandlerGroupConstructor.(frame.LoadEnvironmentConfInterface)
 	if !ok {
-		panic("asyncHandlerGroupConstructor is not frame.LoadEnvironmentConfInterface")
+		return nil, fmt.Errorf("asyncHandlerGroupConstructor is not frame.LoadEnvironmentConfInterface")
 	}
 	err = asyncHandlerGroupConcreteInterface.LoadEnvironmentConf()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	asyncHandlerGroupConstructorInterface, ok := asyncHandlerGroupConstructor.(frame.AsyncHandlerGroupConstructorInterface)
 	if !ok {
-		panic("asyncHandlerGroupConstructor is not frame.AsyncHandlerGroupConstructorInterface")
+		return nil, fmt.Errorf("asyncHandlerGroupConstructor is not frame.AsyncHandlerGroupConstructorInterface")
 	}
 	//////////////////////////////////////////////////////////////////////////////////////////////////////
 	concreteConfManager := concreteconfmanager.NewConcreteConfManager()
 	if confPath != "" {
 		err = concreteConfManager.ParseConfFolder(confPath)
 		if err != nil {
-			panic("concreteConfManager.ParseConfFolder error")
+			return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Path: confPath, Err: err}
 		}
 	}
 
@@ -154,49 +177,72 @@ func NewConstructor(factory frame.FactoryInterface, confPath string) *Constructo
 	constructor.concreteConfManager = concreteConfManager
 	constructor.asyncHandlerGroupConstructorInterface = asyncHandlerGroupConstructorInterface
 	constructor.deepth = -1
+	constructor.registerBuiltinKinds()
 
 	layerConstructorSetterInterface, ok := layerConstructor.(frame.ConstructorSetterInterface)
 	if !ok {
-		panic("layerConstructor is not frame.ConstructorSetterInterface")
+		return nil, fmt.Errorf("layerConstructor is not frame.ConstructorSetterInterface")
 	}
 	layerConstructorSetterInterface.SetConstructorInterface(constructor)
 
 	dividerConstructorSetterInterface, ok := dividerConstructor.(frame.ConstructorSetterInterface)
 	if !ok {
-		panic("dividerConstructor is not frame.ConstructorSetterInterface")
+		return nil, fmt.Errorf("dividerConstructor is not frame.ConstructorSetterInterface")
 	}
 	dividerConstructorSetterInterface.SetConstructorInterface(constructor)
 
 	handlerConstructorSetterInterface, ok := handlerConstructor.(frame.ConstructorSetterInterface)
 	if !ok {
-		panic("handlerConstructor is not frame.ConstructorSetterInterface")
+		return nil, fmt.Errorf("handlerConstructor is not frame.ConstructorSetterInterface")
 	}
 	handlerConstructorSetterInterface.SetConstructorInterface(constructor)
 
 	layerCenterConstructorSetterInterface, ok := layerCenterConstructor.(frame.ConstructorSetterInterface)
 	if !ok {
-		panic("layerCenterConstructor is not frame.ConstructorSetterInterface")
+		return nil, fmt.Errorf("layerCenterConstructor is not frame.ConstructorSetterInterface")
 	}
 	layerCenterConstructorSetterInterface.SetConstructorInterface(constructor)
 
 	handlerGroupConstructorSetterInterface, ok := handlerGroupConstructor.(frame.ConstructorSetterInterface)
 	if !ok {
-		panic("handlerGroupConstructor is not frame.ConstructorSetterInterface")
+		return nil, fmt.Errorf("handlerGroupConstructor is not frame.ConstructorSetterInterface")
 	}
 	handlerGroupConstructorSetterInterface.SetConstructorInterface(constructor)
 
 	asyncHandlerGroupConstructorSetterInterface, ok := asyncHandlerGroupConstructor.(frame.ConstructorSetterInterface)
 	if !ok {
-		panic("asyncHandlerGroupConstructor is not frame.ConstructorSetterInterface")
+		return nil, fmt.Errorf("asyncHandlerGroupConstructor is not frame.ConstructorSetterInterface")
 	}
 	asyncHandlerGroupConstructorSetterInterface.SetConstructorInterface(constructor)
 
+	return constructor, nil
+}
+
+// MustNewConstructor 与NewConstructor相同，失败时panic，适合简单的main函数
+func MustNewConstructor(factory frame.FactoryInterface, confPath string) *Constructor {
+	constructor, err := NewConstructor(factory, confPath)
+	if err != nil {
+		panic(err)
+	}
 	return constructor
 }
 
+// /////////////////////////////////////////////////////////////////////////////////////////////////
+// EnableDebugOverrides 打开后组件才会读取GhGroupsContext中的调试覆盖，线上环境不要打开
+func (c *Constructor) EnableDebugOverrides(enable bool) {
+	c.debugOverrides.Store(enable)
+}
+
+func (c *Constructor) DebugOverridesEnabled() bool {
+	return c.debugOverrides.Load()
+}
+
 // /////////////////////////////////////////////////////////////////////////////////////////////////
 // LayerConstructorInterface
 func (c *Constructor) GetLayer(name string) (frame.LayerBaseInterface, error) {
+	if override, ok, err := overrideOf[frame.LayerBaseInterface](c, name); ok {
+		return override, err
+	}
 	return c.layerConstructorInterface.GetLayer(name)
 }
 
@@ -208,9 +254,25 @@ func (c *Constructor) ParseLayerConfFolder(confFolderPath string) error {
 	return c.layerConstructorInterface.ParseLayerConfFolder(confFolderPath)
 }
 
+// RecordReward 按layer名称回传handler的收益，由layer的divider（例如BanditDivider）更新分配
+func (c *Constructor) RecordReward(layer string, handler string, reward float64) error {
+	layerInterface, err := c.GetLayer(layer)
+	if err != nil {
+		return err
+	}
+	layerRewardRecorderInterface, ok := layerInterface.(frame.LayerRewardRecorderInterface)
+	if !ok {
+		return fmt.Errorf("layer %s does not record rewards", layer)
+	}
+	return layerRewardRecorderInterface.RecordReward(handler, reward)
+}
+
 // /////////////////////////////////////////////////////////////////////////////////////////////////
 // DividerConstructorInterface
 func (c *Constructor) GetDivider(name string) (frame.DividerBaseInterface, error) {
+	if override, ok, err := overrideOf[frame.DividerBaseInterface](c, name); ok {
+		return override, err
+	}
 	return c.dividerConstructorInterface.GetDivider(name)
 }
 
@@ -225,6 +287,9 @@ func (c *Constructor) ParseDividerConfFolder(confFolderPath string) error {
 // /////////////////////////////////////////////////////////////////////////////////////////////////
 // HandlerConstructorInterface
 func (c *Constructor) GetHandler(name string) (frame.HandlerBaseInterface, error) {
+	if override, ok, err := overrideOf[frame.HandlerBaseInterface](c, name); ok {
+		return override, err
+	}
 	return c.handlerConstructorInterface.GetHandler(name)
 }
 
@@ -239,6 +304,9 @@ func (c *Constructor) ParseHandlerConfFolder(confFolderPath string) error {
 // /////////////////////////////////////////////////////////////////////////////////////////////////
 // LayerCenterConstructorInterface
 func (c *Constructor) GetLayerCenter(name string) (frame.LayerCenterBaseInterface, error) {
+	if override, ok, err := overrideOf[frame.LayerCenterBaseInterface](c, name); ok {
+		return override, err
+	}
 	return c.layerCenterConstructorInterface.GetLayerCenter(name)
 }
 
@@ -253,6 +321,9 @@ func (c *Constructor) ParseLayerCenterConfFolder(confFolderPath string) error {
 // /////////////////////////////////////////////////////////////////////////////////////////////////
 // HandlerGroupConstructorInterface
 func (c *Constructor) GetHandlerGroup(name string) (frame.HandlerGroupBaseInterface, error) {
+	if override, ok, err := overrideOf[frame.HandlerGroupBaseInterface](c, name); ok {
+		return override, err
+	}
 	return c.handlerGroupConstructorInterface.GetHandlerGroup(name)
 }
 
@@ -267,6 +338,9 @@ func (c *Constructor) ParseHandlerGroupConfFolder(confFolderPath string) error {
 // /////////////////////////////////////////////////////////////////////////////////////////////////
 // AsyncHandlerConstructorInterface
 func (c *Constructor) GetAsyncHandlerGroup(name string) (frame.AsyncHandlerGroupBaseInterface, error) {
+	if override, ok, err := overrideOf[frame.AsyncHandlerGroupBaseInterface](c, name); ok {
+		return override, err
+	}
 	return c.asyncHandlerGroupConstructorInterface.GetAsyncHandlerGroup(name)
 }
 
@@ -280,22 +354,41 @@ func (c *Constructor) ParseAsyncHandlerGroupConfFolder(confFolderPath string) er
 
 // /////////////////////////////////////////////////////////////////////////////////////////////////
 // FactoryInterface
-func (c *Constructor) Register(concreteType reflect.Type) error {
-	return c.factoryInterface.Register(concreteType)
+func (c *Constructor) Register(concreteType reflect.Type, aliases ...string) error {
+	return c.factoryInterface.Register(concreteType, aliases...)
+}
+
+func (c *Constructor) RegisterFunc(name string, concreteFunc frame.ConcreteFunc, aliases ...string) error {
+	return c.factoryInterface.RegisterFunc(name, concreteFunc, aliases...)
 }
 
 func (c *Constructor) Create(name string, conf []byte, itf any) (any, error) {
-	return c.factoryInterface.Create(name, conf, c)
+	concrete, err := c.factoryInterface.Create(name, conf, c)
+	if err != nil {
+		return nil, err
+	}
+	c.track(concrete)
+	return concrete, nil
+}
+
+// New 创建的实例不会在Close时关闭；per_reference引用处的实例由CreateConcrete创建并记录，per_request池中的实例由引用方持有
+func (c *Constructor) New(name string, conf []byte, itf any) (any, error) {
+	return c.factoryInterface.New(name, conf, c)
 }
 
 func (c *Constructor) Get(name string) (frame.ConcreteInterface, error) {
+	if override, ok, err := overrideOf[frame.ConcreteInterface](c, name); ok {
+		return override, err
+	}
 	return c.factoryInterface.Get(name)
 }
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////
 
 type ConstructorType struct {
-	Type string `yaml:"type"`
+	Type  string `yaml:"type"`
+	Kind  string `yaml:"kind"`
+	Scope string `yaml:"scope"`
 }
 
 const TypeNameHandler = "Handler"
@@ -306,199 +399,257 @@ const TypeNameLayerCenter = "LayerCenter"
 const TypeNameAsyncHandlerGroup = "AsyncHandlerGroup"
 
 func (c *Constructor) createConcreteByTypeName(name string, data []byte) error {
-	if strings.HasSuffix(name, TypeNameAsyncHandlerGroup) {
-		_, err := c.asyncHandlerGroupConstructorInterface.GetAsyncHandlerGroup(name)
-		if err != nil {
-			asyncHandlerGroupInterface, err := c.Create(name, data, c)
-			if err != nil {
-				return err
-			}
-			if namedInterface, ok := asyncHandlerGroupInterface.(frame.ConcreteInterface); ok {
-				return c.asyncHandlerGroupConstructorInterface.RegisterAsyncHandlerGroup(namedInterface.Name(), asyncHandlerGroupInterface.(frame.AsyncHandlerGroupBaseInterface))
-			} else {
-				return c.asyncHandlerGroupConstructorInterface.RegisterAsyncHandlerGroup(name, asyncHandlerGroupInterface.(frame.AsyncHandlerGroupBaseInterface))
-			}
-		}
-		return nil
+	entry, err := c.kindOf(name, "")
+	if errors.Is(err, frame.ErrUnknownType) {
+		return &frame.BuildError{Kind: frame.ErrNotFound, Name: name, Err: fmt.Errorf("object name  %s not found", name)}
 	}
-	if strings.HasPrefix(name, TypeNameHandlerGroup) {
-		_, err := c.handlerGroupConstructorInterface.GetHandlerGroup(name)
-		if err != nil {
-			handlerGroupInterface, err := c.Create(name, data, c)
-			if err != nil {
-				return err
-			}
-			if namedInterface, ok := handlerGroupInterface.(frame.ConcreteInterface); ok {
-				return c.handlerGroupConstructorInterface.RegisterHandlerGroup(namedInterface.Name(), handlerGroupInterface.(frame.HandlerGroupBaseInterface))
-			} else {
-				return c.handlerGroupConstructorInterface.RegisterHandlerGroup(name, handlerGroupInterface.(frame.HandlerGroupBaseInterface))
-			}
-		}
-		return nil
+	if err != nil {
+		return err
 	}
-	if strings.HasSuffix(name, TypeNameDivider) {
-		_, err := c.dividerConstructorInterface.GetDivider(name)
-		if err != nil {
-			dividerInterface, err := c.Create(name, data, c)
-			if err != nil {
-				return err
-			}
-			if namedInterface, ok := dividerInterface.(frame.ConcreteInterface); ok {
-				return c.dividerConstructorInterface.RegisterDivider(namedInterface.Name(), dividerInterface.(frame.DividerBaseInterface))
-			} else {
-				return c.dividerConstructorInterface.RegisterDivider(name, dividerInterface.(frame.DividerBaseInterface))
-			}
-		}
+	return c.createConcreteOfKind(entry, name, data)
+}
+
+// createConcreteOfKind 用Factory创建类型名称为name的组件并登记到kind中
+func (c *Constructor) createConcreteOfKind(entry *kindEntry, name string, data []byte) error {
+	if entry.exists(name) {
 		return nil
 	}
-	if strings.HasSuffix(name, TypeNameHandler) {
-		_, err := c.handlerConstructorInterface.GetHandler(name)
-		if err != nil {
-			handlerInterface, err := c.Create(name, data, c)
-			if err != nil {
-				return err
-			}
-			if namedInterface, ok := handlerInterface.(frame.ConcreteInterface); ok {
-				return c.handlerConstructorInterface.RegisterHandler(namedInterface.Name(), handlerInterface.(frame.HandlerBaseInterface))
-			} else {
-				return c.handlerConstructorInterface.RegisterHandler(name, handlerInterface.(frame.HandlerBaseInterface))
-			}
-		}
-		return nil
+
+	concrete, err := c.Create(name, data, c)
+	if err != nil {
+		return err
 	}
-	if strings.HasSuffix(name, TypeNameLayer) {
-		_, err := c.layerConstructorInterface.GetLayer(name)
-		if err != nil {
-			layerInterface, err := c.Create(name, data, c)
-			if err != nil {
-				return err
-			}
-			if namedInterface, ok := layerInterface.(frame.ConcreteInterface); ok {
-				return c.layerConstructorInterface.RegisterLayer(namedInterface.Name(), layerInterface.(frame.LayerBaseInterface))
-			} else {
-				return c.layerConstructorInterface.RegisterLayer(name, layerInterface.(frame.LayerBaseInterface))
-			}
-		}
-		return nil
+	if !entry.kind.Implements(concrete) {
+		return &frame.BuildError{Kind: frame.ErrNotConcrete, Type: name, Err: fmt.Errorf("%T does not implement %s required by kind %s", concrete, entry.kind.Interface, entry.kind.Name)}
 	}
-	if strings.HasSuffix(name, TypeNameLayerCenter) {
-		_, err := c.layerCenterConstructorInterface.GetLayerCenter(name)
-		if err != nil {
-			layerCenterInterface, err := c.Create(name, data, c)
-			if err != nil {
-				return err
-			}
-			if namedInterface, ok := layerCenterInterface.(frame.ConcreteInterface); ok {
-				return c.layerCenterConstructorInterface.RegisterLayerCenter(namedInterface.Name(), layerCenterInterface.(frame.LayerCenterBaseInterface))
-			} else {
-				return c.layerCenterConstructorInterface.RegisterLayerCenter(name, layerCenterInterface.(frame.LayerCenterBaseInterface))
-			}
-		}
-		return nil
+	if namedInterface, ok := concrete.(frame.ConcreteInterface); ok {
+		return entry.register(namedInterface.Name(), concrete)
 	}
-
-	return fmt.Errorf("object name  %s not found", name)
+	return entry.register(name, concrete)
 }
 
-func (c *Constructor) createConcreteByObjectName(name string) error {
+// readConf 读取name的配置文件
+func (c *Constructor) readConf(name string) (string, []byte, ConstructorType, error) {
+	var constructorType ConstructorType
 	confPath, err := c.concreteConfManager.GetConfPath(name)
 	if err != nil {
-		return err
+		return "", nil, constructorType, err
 	}
-
 	data, err := os.ReadFile(confPath)
 	if err != nil {
-		return err
+		return confPath, nil, constructorType, err
 	}
-
-	var constructorType ConstructorType
 	if err := yaml.Unmarshal(data, &constructorType); err != nil {
+		return confPath, nil, constructorType, err
+	}
+	return confPath, data, constructorType, nil
+}
+
+func (c *Constructor) createConcreteByObjectName(name string, confPath string, data []byte, constructorType ConstructorType) error {
+	if constructorType.Kind == KindResource {
+		_, err := c.Resource(name)
 		return err
 	}
 
-	switch constructorType.Type {
-	case TypeNameHandler:
-		_, err := c.handlerConstructorInterface.GetHandler(name)
-		if err != nil {
-			return c.handlerConstructorInterface.CreateHandlerWithConfPath(confPath)
+	entry, err := c.kindOf(constructorType.Type, constructorType.Kind)
+	if err != nil {
+		return frame.WithBuildInfo(err, name, confPath)
+	}
+
+	scope, err := frame.ParseScope(constructorType.Scope)
+	if err != nil {
+		return &frame.BuildError{Kind: frame.ErrLoadConfig, Type: constructorType.Type, Name: name, Path: confPath, Err: err}
+	}
+	if scope != frame.ScopeSingleton {
+		// 只有handler支持非singleton的作用域，交给HandlerConstructor按作用域创建
+		if entry.kind.Name != TypeNameHandler {
+			return &frame.BuildError{Kind: frame.ErrLoadConfig, Type: constructorType.Type, Name: name, Path: confPath, Err: fmt.Errorf("field scope: %s is only supported by kind %s, %s is kind %s", scope, TypeNameHandler, constructorType.Type, entry.kind.Name)}
 		}
-	case TypeNameDivider:
-		_, err := c.dividerConstructorInterface.GetDivider(name)
-		if err != nil {
-			return c.dividerConstructorInterface.CreateDividerWithConfPath(confPath)
+		if entry.exists(name) {
+			return nil
 		}
-	case TypeNameLayer:
-		_, err := c.layerConstructorInterface.GetLayer(name)
-		if err != nil {
-			return c.layerConstructorInterface.CreateLayerWithConfPath(confPath)
+		return c.handlerConstructorInterface.CreateHandlerWithConfPath(confPath)
+	}
+
+	if err := entry.kind.Parse(data); err != nil {
+		return &frame.BuildError{Kind: frame.ErrLoadConfig, Type: constructorType.Type, Name: name, Path: confPath, Err: err}
+	}
+	if entry.exists(name) {
+		return nil
+	}
+	// type就是内置kind的名称时由对应的子构建器按配置文件创建
+	if entry.createWithConfPath != nil && constructorType.Type == entry.kind.Name {
+		return entry.createWithConfPath(confPath)
+	}
+	return frame.WithBuildInfo(c.createConcreteOfKind(entry, constructorType.Type, data), name, confPath)
+}
+
+func (c *Constructor) CreateConcrete(name string) (err error) {
+	// name还在构建中又被引用时说明存在循环引用，继续构建只会无限递归
+	for i, building := range c.buildStack {
+		if building == name {
+			confPath, _ := c.concreteConfManager.GetConfPath(name)
+			cycle := append(append([]string(nil), c.buildStack[i:]...), name)
+			return &frame.BuildError{Kind: frame.ErrCycle, Name: name, Path: confPath, Err: fmt.Errorf("%s", c.cyclePath(cycle))}
 		}
-	case TypeNameLayerCenter:
-		_, err := c.layerCenterConstructorInterface.GetLayerCenter(name)
+	}
+
+	c.deepth++
+
+	// 记录谁引用了name，Override和Unregister据此判断哪些组合组件需要重新绑定
+	parent, added := "", false
+	if len(c.buildStack) > 0 {
+		parent = c.buildStack[len(c.buildStack)-1]
+		added = c.addDependent(name, parent)
+	}
+	c.buildStack = append(c.buildStack, name)
+
+	defer func() {
+		c.buildStack = c.buildStack[:len(c.buildStack)-1]
+		c.deepth--
+		// 构建失败时撤销这次记录的引用关系，name没有构建出来，也不会引用任何组件
 		if err != nil {
-			return c.layerCenterConstructorInterface.CreateLayerCenterWithConfPath(confPath)
+			if added {
+				c.removeDependent(name, parent)
+			}
+			c.removeParent(name)
 		}
-	case TypeNameHandlerGroup:
-		_, err := c.handlerGroupConstructorInterface.GetHandlerGroup(name)
-		if err != nil {
-			return c.handlerGroupConstructorInterface.CreateHandlerGroupWithConfPath(confPath)
+	}()
+
+	if c.existConcrete(name) {
+		scope := c.handlerScope(name)
+		c.printConcrete(name, scope)
+		if scope == frame.ScopePerReference && !c.overridden(name) {
+			return c.newReference(name)
 		}
-	case TypeNameAsyncHandlerGroup:
-		_, err := c.asyncHandlerGroupConstructorInterface.GetAsyncHandlerGroup(name)
+		return nil
+	}
+	// 被Unregister的组件仍然保留配置，获取时由子构建器按配置重新构建
+	if _, err := c.lookupConcrete(name); err == nil {
+		c.printConcrete(name, c.handlerScope(name))
+		return nil
+	}
+
+	if _, err := c.concreteConfManager.GetConfPath(name); err == nil {
+		confPath, data, constructorType, err := c.readConf(name)
 		if err != nil {
-			return c.asyncHandlerGroupConstructorInterface.CreateAsyncHandlerGroupWithConfPath(confPath)
+			return &frame.BuildError{Kind: frame.ErrLoadConfig, Name: name, Path: confPath, Err: err}
 		}
-	default:
-		err := c.createConcreteByTypeName(constructorType.Type, data)
-		if err != nil {
-			return err
+		scope, errScope := frame.ParseScope(constructorType.Scope)
+		if errScope != nil {
+			scope = frame.Scope(constructorType.Scope)
 		}
-		return nil
+		c.printConcrete(name, scope)
+		return c.createConcreteByObjectName(name, confPath, data, constructorType)
 	}
 
-	return fmt.Errorf("class name  %s not found", name)
+	c.printConcrete(name, frame.ScopeSingleton)
+	return c.createConcreteByTypeName(name, []byte{})
 }
 
-func (c *Constructor) CreateConcrete(name string) error {
-	c.deepth++
+// printConcrete 按构建深度缩进打印组件名称和作用域
+func (c *Constructor) printConcrete(name string, scope frame.Scope) {
 	for i := 0; i < c.deepth; i++ {
 		fmt.Printf("\t")
 	}
-	fmt.Println(name)
+	fmt.Printf("%s [%s]\n", name, scope)
+}
 
-	defer func() {
-		c.deepth--
-	}()
+// handlerScope 返回已经构建的组件的作用域，只有handler支持非singleton的作用域
+func (c *Constructor) handlerScope(name string) frame.Scope {
+	if handlerScopeInterface, ok := c.handlerConstructorInterface.(frame.HandlerScopeInterface); ok {
+		return handlerScopeInterface.Scope(name)
+	}
+	return frame.ScopeSingleton
+}
 
-	_, err := c.GetConcrete(name)
-	if err == nil {
+// newReference 为per_reference的handler的又一个引用处创建实例，紧接着的GetConcrete取走该实例
+// 只有引用它的组合组件会先CreateConcrete再GetConcrete，其余的获取方式都返回登记的实例
+func (c *Constructor) newReference(name string) error {
+	handlerScopeInterface, ok := c.handlerConstructorInterface.(frame.HandlerScopeInterface)
+	if !ok {
 		return nil
 	}
+	handler, err := handlerScopeInterface.NewReference(name)
+	if err != nil {
+		return err
+	}
+	c.track(handler)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.references[name] = handler
+	return nil
+}
 
-	if _, err := c.concreteConfManager.GetConfPath(name); err == nil {
-		err = c.createConcreteByObjectName(name)
-		if err != nil {
-			return err
+func (c *Constructor) overridden(name string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, ok := c.overrides[name]
+	return ok
+}
+
+// cyclePath 把循环上的组件名称连同各自的配置文件拼接成可读的路径
+func (c *Constructor) cyclePath(names []string) string {
+	hops := make([]string, 0, len(names))
+	for _, name := range names {
+		if confPath, err := c.concreteConfManager.GetConfPath(name); err == nil {
+			hops = append(hops, fmt.Sprintf("%s (%s)", name, confPath))
+		} else {
+			hops = append(hops, name)
 		}
-		return nil
 	}
+	return strings.Join(hops, " -> ")
+}
 
-	return c.createConcreteByTypeName(name, []byte{})
+// existConcrete 判断name是否已经构建、被Override或是已经创建的资源，不会按配置创建新的实例
+func (c *Constructor) existConcrete(name string) bool {
+	c.mutex.Lock()
+	_, overridden := c.overrides[name]
+	_, isResource := c.resources[name]
+	c.mutex.Unlock()
+	if overridden || isResource {
+		return true
+	}
+	for _, entry := range c.kindEntries() {
+		if entry.exists(name) {
+			return true
+		}
+	}
+	return false
 }
 
 func (c *Constructor) GetConcrete(name string) (any, error) {
-	if asyncHandlerBaseInterface, err := c.asyncHandlerGroupConstructorInterface.GetAsyncHandlerGroup(name); err == nil {
-		return asyncHandlerBaseInterface, nil
-	} else if handlerBaseInterface, err := c.handlerConstructorInterface.GetHandler(name); err == nil {
-		return handlerBaseInterface, nil
-	} else if dividerBaseInterface, err := c.dividerConstructorInterface.GetDivider(name); err == nil {
-		return dividerBaseInterface, nil
-	} else if layerBaseInterface, err := c.layerConstructorInterface.GetLayer(name); err == nil {
-		return layerBaseInterface, nil
-	} else if layerCenterBaseInterface, err := c.layerCenterConstructorInterface.GetLayerCenter(name); err == nil {
-		return layerCenterBaseInterface, nil
-	} else if handlerGroupBaseInterface, err := c.handlerGroupConstructorInterface.GetHandlerGroup(name); err == nil {
-		return handlerGroupBaseInterface, nil
-	}
-
-	return nil, fmt.Errorf("concrete %s not found", name)
+	c.mutex.Lock()
+	reference, ok := c.references[name]
+	delete(c.references, name)
+	_, overridden := c.overrides[name]
+	c.mutex.Unlock()
+	if ok && !overridden {
+		return reference, nil
+	}
+	return c.lookupConcrete(name)
+}
+
+// lookupConcrete 返回Override的组件或登记的实例，不会取走为per_reference引用处创建的实例
+func (c *Constructor) lookupConcrete(name string) (any, error) {
+	c.mutex.Lock()
+	override, ok := c.overrides[name]
+	c.mutex.Unlock()
+	if ok {
+		return override, nil
+	}
+
+	for _, entry := range c.kindEntries() {
+		if concrete, err := entry.get(name); err == nil {
+			return concrete, nil
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if resource, ok := c.resources[name]; ok {
+		return resource, nil
+	}
+
+	return nil, &frame.BuildError{Kind: frame.ErrNotFound, Name: name}
 }
@@ -0,0 +1,54 @@
+package constructor_test
+
+import (
+	"errors"
+	"ghgroups/frame"
+	handlergroup "ghgroups/frame/handler_group"
+	"ghgroups/frame/layer"
+	"ghgroups/frame/utils"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCycle(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	assert.Nil(t, errGetWd)
+	confPath := path.Join(runPath, "test_data", "cycles")
+
+	constructor := utils.BuildConstructor(confPath)
+	constructor.Register(reflect.TypeOf(handlergroup.HandlerGroup{}))
+	constructor.Register(reflect.TypeOf(layer.Layer{}))
+	constructor.Register(reflect.TypeOf(testNamedHandler{}))
+	constructor.Register(reflect.TypeOf(testFixedDivider{}))
+
+	err := constructor.CreateConcrete("handler_group_a")
+	assert.True(t, errors.Is(err, frame.ErrCycle))
+
+	var buildError *frame.BuildError
+	for e := err; errors.As(e, &buildError); e = buildError.Err {
+		if buildError.Kind == frame.ErrCycle {
+			break
+		}
+	}
+	assert.Equal(t, frame.ErrCycle, buildError.Kind)
+	assert.Equal(t, "handler_group_a", buildError.Name)
+	assert.Equal(t, path.Join(confPath, "handler_group_a.yaml"), buildError.Path)
+	assert.ErrorContains(t, err, "handler_group_a ("+path.Join(confPath, "handler_group_a.yaml")+") -> "+
+		"layer_x ("+path.Join(confPath, "layer_x.yaml")+") -> "+
+		"handler_group_a ("+path.Join(confPath, "handler_group_a.yaml")+")")
+
+	// 出错之后没有留下登记了一半的组件，重试时仍然报告循环引用而不是名称已存在
+	err = constructor.CreateConcrete("handler_group_a")
+	assert.True(t, errors.Is(err, frame.ErrCycle), err)
+	err = constructor.CreateConcrete("layer_x")
+	assert.True(t, errors.Is(err, frame.ErrCycle), err)
+	assert.Empty(t, constructor.Dependents("handler_a"))
+	assert.Empty(t, constructor.Dependents("fixed_divider"))
+
+	// 出错之后构建栈已经清空，不在循环上的组件仍然可以构建
+	assert.Nil(t, constructor.CreateConcrete("handler_a"))
+}
@@ -5,6 +5,7 @@ import (
 	"ghgroups/frame"
 	"os"
 	"path"
+	"sort"
 
 	"reflect"
 
@@ -37,15 +38,17 @@ func (d *DividerConstructor) CreateDividerWithConfPath(confFilePath string) erro
 		return errGetdividerName
 	}
 	if path, ok := d.dividersConfPath[dividerName]; ok {
-		return fmt.Errorf("divider name %s already exists,path is %s", dividerName, path)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: dividerName, Path: path, Err: fmt.Errorf("divider name %s already exists,path is %s", dividerName, path)}
 	}
 	d.dividersConfPath[dividerName] = confFilePath
 	if d.existDivider(dividerName) {
-		return fmt.Errorf("divider %s already exists", dividerName)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: dividerName, Path: confFilePath, Err: fmt.Errorf("divider %s already exists", dividerName)}
 	}
 
 	dividerInterface, err := d.constructDividerFromName(dividerName)
 	if err != nil {
+		// 构建失败时撤销登记的配置文件路径，之后可以按同一个配置文件重新构建
+		delete(d.dividersConfPath, dividerName)
 		return err
 	}
 	err = d.RegisterDivider(dividerName, dividerInterface)
@@ -67,14 +70,19 @@ func (d *DividerConstructor) ParseDividerConfFolder(confFolderPath string) error
 			return errGetdividerName
 		}
 		if path, ok := d.dividersConfPath[dividerName]; ok {
-			return fmt.Errorf("divider name %s already exists,path is %s", dividerName, path)
+			return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: dividerName, Path: path, Err: fmt.Errorf("divider name %s already exists,path is %s", dividerName, path)}
 		}
 		d.dividersConfPath[dividerName] = f
 	}
 
-	for dividerName := range d.dividersConfPath {
+	names := make([]string, 0, len(d.dividersConfPath))
+	for name := range d.dividersConfPath {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, dividerName := range names {
 		if d.existDivider(dividerName) {
-			return fmt.Errorf("divider %s already exists", dividerName)
+			return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: dividerName, Path: d.dividersConfPath[dividerName], Err: fmt.Errorf("divider %s already exists", dividerName)}
 		}
 
 		dividerInterface, err := d.constructDividerFromName(dividerName)
@@ -108,7 +116,7 @@ func (d *DividerConstructor) GetDivider(name string) (frame.DividerBaseInterface
 
 func (d *DividerConstructor) RegisterDivider(name string, divider_interface frame.DividerBaseInterface) error {
 	if d.existDivider(name) {
-		return fmt.Errorf("divider %s is exist", name)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: name, Path: d.dividersConfPath[name], Err: fmt.Errorf("divider %s is exist", name)}
 	}
 	d.dividers[name] = divider_interface
 	return nil
@@ -119,15 +127,15 @@ func (d *DividerConstructor) RegisterDivider(name string, divider_interface fram
 func (d *DividerConstructor) constructDividerFromName(dividerName string) (frame.DividerBaseInterface, error) {
 	dividerConfPath, ok := d.dividersConfPath[dividerName]
 	if !ok {
-		return nil, fmt.Errorf("%s: divider's configuration is not set", dividerName)
+		return nil, &frame.BuildError{Kind: frame.ErrNotFound, Name: dividerName, Err: fmt.Errorf("%s: divider's configuration is not set", dividerName)}
 	}
 	dividerInterface, err := d.constructDividerFromFile(dividerConfPath)
 	if err != nil {
-		return nil, err
+		return nil, frame.WithBuildInfo(err, dividerName, dividerConfPath)
 	}
 
 	if dividerInterface.Name() != dividerName {
-		return nil, fmt.Errorf("divider Name (%s) mismatch with configuration file Name (%s) path (%s)", dividerInterface.Name(), dividerName, dividerConfPath)
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Type: fmt.Sprintf("%T", dividerInterface), Name: dividerName, Path: dividerConfPath, Err: fmt.Errorf("divider Name (%s) mismatch with configuration file Name (%s) path (%s)", dividerInterface.Name(), dividerName, dividerConfPath)}
 	}
 	return dividerInterface, err
 }
@@ -135,12 +143,12 @@ func (d *DividerConstructor) constructDividerFromName(dividerName string) (frame
 func (d *DividerConstructor) constructDividerFromFile(filePath string) (frame.DividerBaseInterface, error) {
 	configure, errReadFile := os.ReadFile(filePath)
 	if errReadFile != nil {
-		return nil, errReadFile
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: errReadFile}
 	}
 	dividerConf := make(map[any]any)
 	errReadFile = yaml.Unmarshal([]byte(configure), &dividerConf)
 	if errReadFile != nil {
-		return nil, errReadFile
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: errReadFile}
 	}
 	return d.constructDivider(dividerConf)
 }
@@ -158,23 +166,23 @@ func (d *DividerConstructor) constructDivider(divider_conf map[any]any) (frame.D
 				}
 				dividerInterface, ok := concrete.(frame.DividerBaseInterface)
 				if !ok {
-					return nil, fmt.Errorf("convert %s to DividerBaseInterface error", typeName)
+					return nil, &frame.BuildError{Kind: frame.ErrNotConcrete, Type: typeName, Err: fmt.Errorf("convert %s to DividerBaseInterface error", typeName)}
 				}
 
 				return dividerInterface, nil
 			}
 		}
 	}
-	return nil, fmt.Errorf("divider configuration must have type %v", divider_conf)
+	return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Err: fmt.Errorf("divider configuration must have type %v", divider_conf)}
 }
 
 func (d *DividerConstructor) getDividerName(filePath string) (string, error) {
 	s, err := os.Stat(filePath)
 	if err != nil {
-		return "", err
+		return "", &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: err}
 	}
 	if s.IsDir() {
-		return "", fmt.Errorf("file path %s is not a directory", filePath)
+		return "", &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: fmt.Errorf("file path %s is not a directory", filePath)}
 	}
 
 	fileNameWithExt := path.Base(filePath)
@@ -188,6 +196,18 @@ func (d *DividerConstructor) existDivider(name string) bool {
 	return exist
 }
 
+// Exist 判断组件是否已经构建，不会按配置创建
+func (d *DividerConstructor) Exist(name string) bool {
+	return d.existDivider(name)
+}
+
+// Unregister 删除已经构建的divider，之后再次获取时按配置重新构建
+func (d *DividerConstructor) Unregister(name string) bool {
+	_, exist := d.dividers[name]
+	delete(d.dividers, name)
+	return exist
+}
+
 func (d *DividerConstructor) LoadConfigFromFile(confPath string) error {
 	return nil
 }
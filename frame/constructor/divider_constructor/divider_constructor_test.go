@@ -1,6 +1,8 @@
 package dividerconstructor
 
 import (
+	"errors"
+	"ghgroups/frame"
 	"os"
 	"path"
 	"reflect"
@@ -95,7 +97,7 @@ func TestInit(t *testing.T) {
 	factory.Register(reflect.TypeOf(layercenterconstructor.LayerCenterConstructor{}))
 	factory.Register(reflect.TypeOf(handlergroupconstructor.HandlerGroupConstructor{}))
 	factory.Register(reflect.TypeOf(aynchandlergroupconstructor.AsyncHandlerGroupConstructor{}))
-	constructor := constructor.NewConstructor(factory, "")
+	constructor := constructor.MustNewConstructor(factory, "")
 	constructor.Register(reflect.TypeOf(sampledivider.SampleAutoConstructDivider{}))
 
 	t.Run("Input=valid", func(t *testing.T) {
@@ -138,6 +140,7 @@ func TestInit(t *testing.T) {
 		dividerConstructor := NewDividerConstructor(constructor)
 		err := dividerConstructor.ParseDividerConfFolder(confPath)
 		assert.ErrorContains(t, err, "mismatch with configuration file Name")
+		assert.True(t, errors.Is(err, frame.ErrLoadConfig))
 	})
 
 	t.Run("Input=invalid/same_divider_name", func(t *testing.T) {
@@ -163,14 +166,13 @@ func TestInit(t *testing.T) {
 		confPath := path.Join(testDataPath, confName)
 		assert.NoFileExists(t, confPath)
 
-		defer func() {
-			r := recover()
-			assert.Contains(t, r, "concrete's Name  sample_f has already been used")
-		}()
-
 		dividerConstructor := NewDividerConstructor(constructor)
-		dividerConstructor.ParseDividerConfFolder(confPath)
-		assert.FailNow(t, "must panic when divider name is the same")
+		err := dividerConstructor.ParseDividerConfFolder(confPath)
+		assert.True(t, errors.Is(err, frame.ErrDuplicateName))
+		var buildError *frame.BuildError
+		assert.True(t, errors.As(err, &buildError))
+		assert.Equal(t, "sample_f", buildError.Name)
+		assert.Equal(t, path.Join(confPath, "second", "sample_g.yaml"), buildError.Path)
 	})
 
 	t.Run("Input=invalid/no_type", func(t *testing.T) {
@@ -185,6 +187,7 @@ func TestInit(t *testing.T) {
 		dividerConstructor := NewDividerConstructor(constructor)
 		err := dividerConstructor.ParseDividerConfFolder(confPath)
 		assert.ErrorContains(t, err, "divider configuration must have type")
+		assert.True(t, errors.Is(err, frame.ErrLoadConfig))
 	})
 }
 
@@ -200,7 +203,7 @@ func TestConstructDividerFromFile(t *testing.T) {
 	factory.Register(reflect.TypeOf(layercenterconstructor.LayerCenterConstructor{}))
 	factory.Register(reflect.TypeOf(handlergroupconstructor.HandlerGroupConstructor{}))
 	factory.Register(reflect.TypeOf(aynchandlergroupconstructor.AsyncHandlerGroupConstructor{}))
-	constructor := constructor.NewConstructor(factory, "")
+	constructor := constructor.MustNewConstructor(factory, "")
 	constructor.Register(reflect.TypeOf(sampledivider.SampleAutoConstructDivider{}))
 
 	t.Run("Input=InvalidFilePath", func(t *testing.T) {
@@ -225,6 +228,7 @@ func TestConstructDividerFromFile(t *testing.T) {
 		dividerInterface, err := dividerConstructor.constructDividerFromFile(confFilePath)
 		assert.Nil(t, dividerInterface)
 		assert.ErrorContains(t, err, "cannot unmarshal")
+		assert.True(t, errors.Is(err, frame.ErrLoadConfig))
 	})
 }
 
@@ -240,7 +244,7 @@ func TestConstructDividerFromName(t *testing.T) {
 	factory.Register(reflect.TypeOf(layercenterconstructor.LayerCenterConstructor{}))
 	factory.Register(reflect.TypeOf(handlergroupconstructor.HandlerGroupConstructor{}))
 	factory.Register(reflect.TypeOf(aynchandlergroupconstructor.AsyncHandlerGroupConstructor{}))
-	constructor := constructor.NewConstructor(factory, "")
+	constructor := constructor.MustNewConstructor(factory, "")
 
 	constructor.Register(reflect.TypeOf(sampledivider.SampleAutoConstructDivider{}))
 	constructor.Register(reflect.TypeOf(SampleWithoutSelectDivider{}))
@@ -250,6 +254,7 @@ func TestConstructDividerFromName(t *testing.T) {
 		dividerInterface, err := dividerConstructor.constructDividerFromName("")
 		assert.Nil(t, dividerInterface)
 		assert.ErrorContains(t, err, "divider's configuration is not set")
+		assert.True(t, errors.Is(err, frame.ErrNotFound))
 	})
 
 	t.Run("Input=invalid/error_unmarshal", func(t *testing.T) {
@@ -267,6 +272,7 @@ func TestConstructDividerFromName(t *testing.T) {
 		dividerInterface, err := dividerConstructor.constructDividerFromFile(confFilePath)
 		assert.Nil(t, dividerInterface)
 		assert.ErrorContains(t, err, "cannot unmarshal")
+		assert.True(t, errors.Is(err, frame.ErrLoadConfig))
 	})
 
 	t.Run("Input=invalid/divider_without_handle", func(t *testing.T) {
@@ -5,6 +5,7 @@ import (
 	"ghgroups/frame"
 	"os"
 	"path"
+	"sort"
 
 	"reflect"
 
@@ -18,6 +19,7 @@ type HandlerConstructor struct {
 	constructorInterface frame.ConstructorInterface
 	handlers             map[string]frame.HandlerBaseInterface
 	handlersConfPath     map[string]string
+	scopes               map[string]frame.Scope
 }
 
 func NewHandlerConstructor(constructorInterface frame.ConstructorInterface) *HandlerConstructor {
@@ -25,6 +27,7 @@ func NewHandlerConstructor(constructorInterface frame.ConstructorInterface) *Han
 		constructorInterface: constructorInterface,
 		handlers:             make(map[string]frame.HandlerBaseInterface),
 		handlersConfPath:     make(map[string]string),
+		scopes:               make(map[string]frame.Scope),
 	}
 }
 
@@ -37,15 +40,17 @@ func (h *HandlerConstructor) CreateHandlerWithConfPath(confFilePath string) erro
 		return errGethandlerName
 	}
 	if path, ok := h.handlersConfPath[handlerName]; ok {
-		return fmt.Errorf("handler name %s already exists,path is %s", handlerName, path)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: handlerName, Path: path, Err: fmt.Errorf("handler name %s already exists,path is %s", handlerName, path)}
 	}
 	h.handlersConfPath[handlerName] = confFilePath
 	if h.existHandler(handlerName) {
-		return fmt.Errorf("handler %s already exists", handlerName)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: handlerName, Path: confFilePath, Err: fmt.Errorf("handler %s already exists", handlerName)}
 	}
 
 	handlerInterface, err := h.constructHandlerFromName(handlerName)
 	if err != nil {
+		// 构建失败时撤销登记的配置文件路径，之后可以按同一个配置文件重新构建
+		delete(h.handlersConfPath, handlerName)
 		return err
 	}
 	err = h.RegisterHandler(handlerName, handlerInterface)
@@ -67,14 +72,19 @@ func (h *HandlerConstructor) ParseHandlerConfFolder(confFolderPath string) error
 			return errGethandlerName
 		}
 		if path, ok := h.handlersConfPath[handlerName]; ok {
-			return fmt.Errorf("handler name %s already exists,path is %s", handlerName, path)
+			return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: handlerName, Path: path, Err: fmt.Errorf("handler name %s already exists,path is %s", handlerName, path)}
 		}
 		h.handlersConfPath[handlerName] = f
 	}
 
-	for handlerName := range h.handlersConfPath {
+	names := make([]string, 0, len(h.handlersConfPath))
+	for name := range h.handlersConfPath {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, handlerName := range names {
 		if h.existHandler(handlerName) {
-			return fmt.Errorf("handler %s already exists", handlerName)
+			return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: handlerName, Path: h.handlersConfPath[handlerName], Err: fmt.Errorf("handler %s already exists", handlerName)}
 		}
 
 		handlerInterface, err := h.constructHandlerFromName(handlerName)
@@ -108,26 +118,60 @@ func (h *HandlerConstructor) GetHandler(name string) (frame.HandlerBaseInterface
 
 func (h *HandlerConstructor) RegisterHandler(name string, handler_interface frame.HandlerBaseInterface) error {
 	if h.existHandler(name) {
-		return fmt.Errorf("handler %s is exist", name)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: name, Path: h.handlersConfPath[name], Err: fmt.Errorf("handler %s is exist", name)}
 	}
 	h.handlers[name] = handler_interface
 	return nil
 }
 
+// Scope 返回handler的作用域，没有配置过的handler是ScopeSingleton
+func (h *HandlerConstructor) Scope(name string) frame.Scope {
+	if scope, ok := h.scopes[name]; ok {
+		return scope
+	}
+	return frame.ScopeSingleton
+}
+
+// NewReference 为已经构建的per_reference handler创建一个不登记名称的新实例，由Constructor交给又一个引用它的组合组件
+func (h *HandlerConstructor) NewReference(name string) (frame.HandlerBaseInterface, error) {
+	if scope := h.Scope(name); scope != frame.ScopePerReference {
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Name: name, Path: h.handlersConfPath[name], Err: fmt.Errorf("handler %s scope is %s, not %s", name, scope, frame.ScopePerReference)}
+	}
+	handlerConfPath := h.handlersConfPath[name]
+	configure, err := os.ReadFile(handlerConfPath)
+	if err != nil {
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Name: name, Path: handlerConfPath, Err: err}
+	}
+	handlerConf := struct {
+		Type string `yaml:"type"`
+	}{}
+	if err := yaml.Unmarshal(configure, &handlerConf); err != nil {
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Name: name, Path: handlerConfPath, Err: err}
+	}
+	handlerInterface, err := h.newHandler(handlerConf.Type, configure)
+	if err != nil {
+		return nil, frame.WithBuildInfo(err, name, handlerConfPath)
+	}
+	if handlerInterface.Name() != name {
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Type: fmt.Sprintf("%T", handlerInterface), Name: name, Path: handlerConfPath, Err: fmt.Errorf("handler Name (%s) mismatch with configuration file Name (%s) path (%s)", handlerInterface.Name(), name, handlerConfPath)}
+	}
+	return handlerInterface, nil
+}
+
 /////////////////////////////////////////////////////////////////////////////////////////////////////
 
 func (h *HandlerConstructor) constructHandlerFromName(handlerName string) (frame.HandlerBaseInterface, error) {
 	handlerConfPath, ok := h.handlersConfPath[handlerName]
 	if !ok {
-		return nil, fmt.Errorf("%s: handler's configuration is not set", handlerName)
+		return nil, &frame.BuildError{Kind: frame.ErrNotFound, Name: handlerName, Err: fmt.Errorf("%s: handler's configuration is not set", handlerName)}
 	}
 	handlerInterface, err := h.constructHandlerFromFile(handlerConfPath)
 	if err != nil {
-		return nil, err
+		return nil, frame.WithBuildInfo(err, handlerName, handlerConfPath)
 	}
 
 	if handlerInterface.Name() != handlerName {
-		return nil, fmt.Errorf("handler Name (%s) mismatch with configuration file Name (%s) path (%s)", handlerInterface.Name(), handlerName, handlerConfPath)
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Type: fmt.Sprintf("%T", handlerInterface), Name: handlerName, Path: handlerConfPath, Err: fmt.Errorf("handler Name (%s) mismatch with configuration file Name (%s) path (%s)", handlerInterface.Name(), handlerName, handlerConfPath)}
 	}
 	return handlerInterface, err
 }
@@ -135,46 +179,71 @@ func (h *HandlerConstructor) constructHandlerFromName(handlerName string) (frame
 func (h *HandlerConstructor) constructHandlerFromFile(filePath string) (frame.HandlerBaseInterface, error) {
 	configure, errReadFile := os.ReadFile(filePath)
 	if errReadFile != nil {
-		return nil, errReadFile
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: errReadFile}
 	}
 	handlerConf := make(map[any]any)
 	errReadFile = yaml.Unmarshal([]byte(configure), &handlerConf)
 	if errReadFile != nil {
-		return nil, errReadFile
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: errReadFile}
 	}
 	return h.constructHandler(handlerConf)
 }
 
 func (h *HandlerConstructor) constructHandler(handler_conf map[any]any) (frame.HandlerBaseInterface, error) {
-	for k, v := range handler_conf {
-		switch k.(type) {
-		case string:
-			if k == "type" {
-				originConf, _ := yaml.Marshal(handler_conf)
-				typeName := v.(string)
-				concrete, err := h.constructorInterface.Create(typeName, originConf, h.constructorInterface)
-				if err != nil {
-					return nil, err
-				}
-				handlerInterface, ok := concrete.(frame.HandlerBaseInterface)
-				if !ok {
-					return nil, fmt.Errorf("convert %s to HandlerBaseInterface error", typeName)
-				}
-
-				return handlerInterface, nil
+	typeName, ok := handler_conf["type"].(string)
+	if !ok {
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Err: fmt.Errorf("handler configuration must have type %v", handler_conf)}
+	}
+	scope, err := frame.ParseScope(handler_conf["scope"])
+	if err != nil {
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Type: typeName, Err: err}
+	}
+	originConf, _ := yaml.Marshal(handler_conf)
+
+	// per_reference登记的实例与singleton一样创建，它就是第一个引用处的实例，之后的引用处通过NewReference创建
+	var handlerInterface frame.HandlerBaseInterface
+	switch scope {
+	case frame.ScopePerRequest:
+		handlerInterface, err = newPooledHandler(func() (frame.HandlerBaseInterface, error) {
+			return h.newHandler(typeName, originConf)
+		})
+	default:
+		var concrete any
+		concrete, err = h.constructorInterface.Create(typeName, originConf, h.constructorInterface)
+		if err == nil {
+			handlerInterface, ok = concrete.(frame.HandlerBaseInterface)
+			if !ok {
+				return nil, &frame.BuildError{Kind: frame.ErrNotConcrete, Type: typeName, Err: fmt.Errorf("convert %s to HandlerBaseInterface error", typeName)}
 			}
 		}
 	}
-	return nil, fmt.Errorf("handler configuration must have type %v", handler_conf)
+	if err != nil {
+		return nil, err
+	}
+	h.scopes[handlerInterface.Name()] = scope
+	return handlerInterface, nil
+}
+
+// newHandler 创建不登记名称的handler实例，供per_reference之后的引用处和per_request使用
+func (h *HandlerConstructor) newHandler(typeName string, conf []byte) (frame.HandlerBaseInterface, error) {
+	concrete, err := h.constructorInterface.New(typeName, conf, h.constructorInterface)
+	if err != nil {
+		return nil, err
+	}
+	handlerInterface, ok := concrete.(frame.HandlerBaseInterface)
+	if !ok {
+		return nil, &frame.BuildError{Kind: frame.ErrNotConcrete, Type: typeName, Err: fmt.Errorf("convert %s to HandlerBaseInterface error", typeName)}
+	}
+	return handlerInterface, nil
 }
 
 func (h *HandlerConstructor) getHandlerName(filePath string) (string, error) {
 	s, err := os.Stat(filePath)
 	if err != nil {
-		return "", err
+		return "", &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: err}
 	}
 	if s.IsDir() {
-		return "", fmt.Errorf("file path %s is not a directory", filePath)
+		return "", &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: fmt.Errorf("file path %s is not a directory", filePath)}
 	}
 
 	fileNameWithExt := path.Base(filePath)
@@ -188,6 +257,18 @@ func (h *HandlerConstructor) existHandler(name string) bool {
 	return exist
 }
 
+// Exist 判断组件是否已经构建，不会按配置创建
+func (h *HandlerConstructor) Exist(name string) bool {
+	return h.existHandler(name)
+}
+
+// Unregister 删除已经构建的handler，之后再次获取时按配置重新构建
+func (h *HandlerConstructor) Unregister(name string) bool {
+	_, exist := h.handlers[name]
+	delete(h.handlers, name)
+	return exist
+}
+
 func (h *HandlerConstructor) LoadConfigFromFile(confPath string) error {
 	return nil
 }
@@ -199,6 +280,7 @@ func (h *HandlerConstructor) LoadConfigFromMemory(configure []byte) error {
 func (h *HandlerConstructor) LoadEnvironmentConf() error {
 	h.handlers = make(map[string]frame.HandlerBaseInterface)
 	h.handlersConfPath = make(map[string]string)
+	h.scopes = make(map[string]frame.Scope)
 	return nil
 }
 
@@ -1,6 +1,8 @@
 package handlerconstructor
 
 import (
+	"errors"
+	"ghgroups/frame"
 	"os"
 	"path"
 	"reflect"
@@ -94,7 +96,7 @@ func TestInit(t *testing.T) {
 	factory.Register(reflect.TypeOf(layercenterconstructor.LayerCenterConstructor{}))
 	factory.Register(reflect.TypeOf(handlergroupconstructor.HandlerGroupConstructor{}))
 	factory.Register(reflect.TypeOf(aynchandlergroupconstructor.AsyncHandlerGroupConstructor{}))
-	constructor := constructor.NewConstructor(factory, "")
+	constructor := constructor.MustNewConstructor(factory, "")
 
 	err := constructor.Register(reflect.TypeOf(samplehandler.SampleAutoConstructHandler{}))
 	assert.Nil(t, err)
@@ -139,6 +141,7 @@ func TestInit(t *testing.T) {
 		handlerConstructor := NewHandlerConstructor(constructor)
 		err := handlerConstructor.ParseHandlerConfFolder(confPath)
 		assert.ErrorContains(t, err, "mismatch with configuration file Name")
+		assert.True(t, errors.Is(err, frame.ErrLoadConfig))
 	})
 
 	t.Run("Input=invalid/same_handler_name", func(t *testing.T) {
@@ -164,14 +167,13 @@ func TestInit(t *testing.T) {
 		confPath := path.Join(testDataPath, confName)
 		assert.NoFileExists(t, confPath)
 
-		defer func() {
-			r := recover()
-			assert.Contains(t, r, "concrete's Name  sample_f has already been used")
-		}()
-
 		handlerConstructor := NewHandlerConstructor(constructor)
-		handlerConstructor.ParseHandlerConfFolder(confPath)
-		assert.FailNow(t, "must panic when handler name is the same")
+		err := handlerConstructor.ParseHandlerConfFolder(confPath)
+		assert.True(t, errors.Is(err, frame.ErrDuplicateName))
+		var buildError *frame.BuildError
+		assert.True(t, errors.As(err, &buildError))
+		assert.Equal(t, "sample_f", buildError.Name)
+		assert.Equal(t, path.Join(confPath, "second", "sample_g.yaml"), buildError.Path)
 	})
 
 	t.Run("Input=invalid/no_type", func(t *testing.T) {
@@ -186,6 +188,7 @@ func TestInit(t *testing.T) {
 		handlerConstructor := NewHandlerConstructor(constructor)
 		err := handlerConstructor.ParseHandlerConfFolder(confPath)
 		assert.ErrorContains(t, err, "handler configuration must have type")
+		assert.True(t, errors.Is(err, frame.ErrLoadConfig))
 	})
 }
 
@@ -201,7 +204,7 @@ func TestConstructHandlerFromFile(t *testing.T) {
 	factory.Register(reflect.TypeOf(layercenterconstructor.LayerCenterConstructor{}))
 	factory.Register(reflect.TypeOf(handlergroupconstructor.HandlerGroupConstructor{}))
 	factory.Register(reflect.TypeOf(aynchandlergroupconstructor.AsyncHandlerGroupConstructor{}))
-	constructor := constructor.NewConstructor(factory, "")
+	constructor := constructor.MustNewConstructor(factory, "")
 
 	t.Run("Input=InvalidFilePath", func(t *testing.T) {
 		handlerConstructor := NewHandlerConstructor(constructor)
@@ -224,6 +227,7 @@ func TestConstructHandlerFromFile(t *testing.T) {
 		handlerInterface, err := handlerConstructor.constructHandlerFromFile(confFilePath)
 		assert.Nil(t, handlerInterface)
 		assert.ErrorContains(t, err, "cannot unmarshal")
+		assert.True(t, errors.Is(err, frame.ErrLoadConfig))
 	})
 }
 
@@ -239,7 +243,7 @@ func TestConstructHandlerFromName(t *testing.T) {
 	factory.Register(reflect.TypeOf(layercenterconstructor.LayerCenterConstructor{}))
 	factory.Register(reflect.TypeOf(handlergroupconstructor.HandlerGroupConstructor{}))
 	factory.Register(reflect.TypeOf(aynchandlergroupconstructor.AsyncHandlerGroupConstructor{}))
-	constructor := constructor.NewConstructor(factory, "")
+	constructor := constructor.MustNewConstructor(factory, "")
 
 	err := constructor.Register(reflect.TypeOf(SampleWithoutHandleHandler{}))
 	assert.Nil(t, err)
@@ -249,6 +253,7 @@ func TestConstructHandlerFromName(t *testing.T) {
 		handlerInterface, err := handlerConstructor.constructHandlerFromName("")
 		assert.Nil(t, handlerInterface)
 		assert.ErrorContains(t, err, "handler's configuration is not set")
+		assert.True(t, errors.Is(err, frame.ErrNotFound))
 	})
 
 	t.Run("Input=invalid/error_unmarshal", func(t *testing.T) {
@@ -265,6 +270,7 @@ func TestConstructHandlerFromName(t *testing.T) {
 		handlerInterface, err := handlerConstructor.constructHandlerFromFile(confFilePath)
 		assert.Nil(t, handlerInterface)
 		assert.ErrorContains(t, err, "cannot unmarshal")
+		assert.True(t, errors.Is(err, frame.ErrLoadConfig))
 	})
 
 	t.Run("Input=invalid/handler_without_handle", func(t *testing.T) {
@@ -281,6 +287,7 @@ func TestConstructHandlerFromName(t *testing.T) {
 		handlerInterface, err := handlerConstructor.constructHandlerFromFile(confFilePath)
 		assert.Nil(t, handlerInterface)
 		assert.ErrorContains(t, err, "convert SampleWithoutHandleHandler to HandlerBaseInterface error")
+		assert.True(t, errors.Is(err, frame.ErrNotConcrete))
 	})
 }
 
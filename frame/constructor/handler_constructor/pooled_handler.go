@@ -0,0 +1,54 @@
+package handlerconstructor
+
+import (
+	"ghgroups/frame"
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+	"sync"
+)
+
+// pooledHandler 是per_request组件在各个引用处共享的代理，每次Handle从池中取出一个实例，执行结束后重置并放回
+type pooledHandler struct {
+	frame.HandlerBaseInterface
+	name string
+	pool sync.Pool
+}
+
+func newPooledHandler(newInstance func() (frame.HandlerBaseInterface, error)) (*pooledHandler, error) {
+	// 先创建一个实例，配置错误在构建阶段就能暴露出来
+	instance, err := newInstance()
+	if err != nil {
+		return nil, err
+	}
+	pooled := &pooledHandler{name: instance.Name()}
+	pooled.pool.New = func() any {
+		instance, err := newInstance()
+		if err != nil {
+			return err
+		}
+		return instance
+	}
+	pooled.pool.Put(instance)
+	return pooled, nil
+}
+
+func (p *pooledHandler) Name() string {
+	return p.name
+}
+
+func (p *pooledHandler) Handle(context *ghgroupscontext.GhGroupsContext) bool {
+	var instance frame.HandlerBaseInterface
+	switch pooled := p.pool.Get().(type) {
+	case error:
+		context.ReportError(pooled)
+		return false
+	case frame.HandlerBaseInterface:
+		instance = pooled
+	}
+	defer func() {
+		if resetInterface, ok := instance.(frame.ResetInterface); ok {
+			resetInterface.Reset()
+		}
+		p.pool.Put(instance)
+	}()
+	return instance.Handle(context)
+}
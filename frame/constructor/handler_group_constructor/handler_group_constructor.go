@@ -6,6 +6,7 @@ import (
 	"os"
 	"path"
 	"reflect"
+	"sort"
 
 	folder "git-codecommit.us-east-1.amazonaws.com/v1/repos/go-utils.git/folder"
 
@@ -37,15 +38,17 @@ func (h *HandlerGroupConstructor) CreateHandlerGroupWithConfPath(confFilePath st
 		return errGethandlerName
 	}
 	if path, ok := h.handlersConfPath[handlerName]; ok {
-		return fmt.Errorf("handler name %s already exists,path is %s", handlerName, path)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: handlerName, Path: path, Err: fmt.Errorf("handler name %s already exists,path is %s", handlerName, path)}
 	}
 	h.handlersConfPath[handlerName] = confFilePath
 	if h.existHandlerGroup(handlerName) {
-		return fmt.Errorf("handler %s already exists", handlerName)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: handlerName, Path: confFilePath, Err: fmt.Errorf("handler %s already exists", handlerName)}
 	}
 
 	handlerInterface, err := h.constructHandlerGroupFromName(handlerName)
 	if err != nil {
+		// 构建失败时撤销登记的配置文件路径，之后可以按同一个配置文件重新构建
+		delete(h.handlersConfPath, handlerName)
 		return err
 	}
 	err = h.RegisterHandlerGroup(handlerName, handlerInterface)
@@ -67,14 +70,19 @@ func (h *HandlerGroupConstructor) ParseHandlerGroupConfFolder(confFolderPath str
 			return errGethandlerName
 		}
 		if path, ok := h.handlersConfPath[handlerName]; ok {
-			return fmt.Errorf("handler name %s already exists,path is %s", handlerName, path)
+			return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: handlerName, Path: path, Err: fmt.Errorf("handler name %s already exists,path is %s", handlerName, path)}
 		}
 		h.handlersConfPath[handlerName] = f
 	}
 
-	for handlerName := range h.handlersConfPath {
+	names := make([]string, 0, len(h.handlersConfPath))
+	for name := range h.handlersConfPath {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, handlerName := range names {
 		if h.existHandlerGroup(handlerName) {
-			return fmt.Errorf("handler %s already exists", handlerName)
+			return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: handlerName, Path: h.handlersConfPath[handlerName], Err: fmt.Errorf("handler %s already exists", handlerName)}
 		}
 
 		handlerInterface, err := h.constructHandlerGroupFromName(handlerName)
@@ -108,7 +116,7 @@ func (h *HandlerGroupConstructor) GetHandlerGroup(name string) (frame.HandlerGro
 
 func (h *HandlerGroupConstructor) RegisterHandlerGroup(name string, handler_interface frame.HandlerGroupBaseInterface) error {
 	if h.existHandlerGroup(name) {
-		return fmt.Errorf("handler %s is exist", name)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: name, Path: h.handlersConfPath[name], Err: fmt.Errorf("handler %s is exist", name)}
 	}
 	h.handlers[name] = handler_interface
 	return nil
@@ -119,15 +127,15 @@ func (h *HandlerGroupConstructor) RegisterHandlerGroup(name string, handler_inte
 func (h *HandlerGroupConstructor) constructHandlerGroupFromName(handlerName string) (frame.HandlerGroupBaseInterface, error) {
 	handlerConfPath, ok := h.handlersConfPath[handlerName]
 	if !ok {
-		return nil, fmt.Errorf("%s: handler's configuration is not set", handlerName)
+		return nil, &frame.BuildError{Kind: frame.ErrNotFound, Name: handlerName, Err: fmt.Errorf("%s: handler's configuration is not set", handlerName)}
 	}
 	handlerInterface, err := h.constructHandlerGroupFromFile(handlerConfPath)
 	if err != nil {
-		return nil, err
+		return nil, frame.WithBuildInfo(err, handlerName, handlerConfPath)
 	}
 
 	if handlerInterface.Name() != handlerName {
-		return nil, fmt.Errorf("handler Name (%s) mismatch with configuration file Name (%s) path (%s)", handlerInterface.Name(), handlerName, handlerConfPath)
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Type: fmt.Sprintf("%T", handlerInterface), Name: handlerName, Path: handlerConfPath, Err: fmt.Errorf("handler Name (%s) mismatch with configuration file Name (%s) path (%s)", handlerInterface.Name(), handlerName, handlerConfPath)}
 	}
 	return handlerInterface, err
 }
@@ -135,12 +143,12 @@ func (h *HandlerGroupConstructor) constructHandlerGroupFromName(handlerName stri
 func (h *HandlerGroupConstructor) constructHandlerGroupFromFile(filePath string) (frame.HandlerGroupBaseInterface, error) {
 	configure, errReadFile := os.ReadFile(filePath)
 	if errReadFile != nil {
-		return nil, errReadFile
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: errReadFile}
 	}
 	handlerConf := make(map[any]any)
 	errReadFile = yaml.Unmarshal([]byte(configure), &handlerConf)
 	if errReadFile != nil {
-		return nil, errReadFile
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: errReadFile}
 	}
 	return h.constructHandlerGroup(handlerConf)
 }
@@ -158,23 +166,23 @@ func (h *HandlerGroupConstructor) constructHandlerGroup(handler_conf map[any]any
 				}
 				handlerInterface, ok := concrete.(frame.HandlerGroupBaseInterface)
 				if !ok {
-					return nil, fmt.Errorf("convert %s to HandlerGroupBaseInterface error", typeName)
+					return nil, &frame.BuildError{Kind: frame.ErrNotConcrete, Type: typeName, Err: fmt.Errorf("convert %s to HandlerGroupBaseInterface error", typeName)}
 				}
 
 				return handlerInterface, nil
 			}
 		}
 	}
-	return nil, fmt.Errorf("handler configuration must have type %v", handler_conf)
+	return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Err: fmt.Errorf("handler configuration must have type %v", handler_conf)}
 }
 
 func (h *HandlerGroupConstructor) getHandlerGroupName(filePath string) (string, error) {
 	s, err := os.Stat(filePath)
 	if err != nil {
-		return "", err
+		return "", &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: err}
 	}
 	if s.IsDir() {
-		return "", fmt.Errorf("file path %s is not a directory", filePath)
+		return "", &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: fmt.Errorf("file path %s is not a directory", filePath)}
 	}
 
 	fileNameWithExt := path.Base(filePath)
@@ -188,6 +196,18 @@ func (h *HandlerGroupConstructor) existHandlerGroup(name string) bool {
 	return exist
 }
 
+// Exist 判断组件是否已经构建，不会按配置创建
+func (h *HandlerGroupConstructor) Exist(name string) bool {
+	return h.existHandlerGroup(name)
+}
+
+// Unregister 删除已经构建的handler group，之后再次获取时按配置重新构建
+func (h *HandlerGroupConstructor) Unregister(name string) bool {
+	_, exist := h.handlers[name]
+	delete(h.handlers, name)
+	return exist
+}
+
 func (h *HandlerGroupConstructor) LoadConfigFromFile(confPath string) error {
 	return nil
 }
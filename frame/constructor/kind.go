@@ -0,0 +1,363 @@
+package constructor
+
+import (
+	"fmt"
+	"ghgroups/frame"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// kindEntry 是注册到Constructor中的kind，以及保存该kind组件的方式
+// 内置kind的组件保存在各自的子构建器中，自定义kind的组件保存在Constructor中
+type kindEntry struct {
+	kind               frame.Kind
+	get                func(name string) (any, error)
+	register           func(name string, concrete any) error
+	unregister         func(name string)
+	exist              func(name string) bool
+	createWithConfPath func(confPath string) error
+}
+
+// RegisterKind 注册自定义的组件kind，之后类型名称属于该kind的组件可以通过CreateConcrete构建、通过GetConcrete获取
+// 自定义kind先于内置kind匹配
+func (c *Constructor) RegisterKind(kind frame.Kind) error {
+	if kind.Name == "" {
+		return &frame.BuildError{Kind: frame.ErrEmptyName, Err: fmt.Errorf("kind name is empty")}
+	}
+	if kind.Interface != nil && kind.Interface.Kind() != reflect.Interface {
+		return fmt.Errorf("kind %s Interface %s is not an interface", kind.Name, kind.Interface)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, entry := range c.kinds {
+		if entry.kind.Name == kind.Name {
+			return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: kind.Name, Err: fmt.Errorf("kind already registered")}
+		}
+	}
+
+	concretes := make(map[string]any)
+	entry := &kindEntry{
+		kind: kind,
+		get: func(name string) (any, error) {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			if concrete, ok := concretes[name]; ok {
+				return concrete, nil
+			}
+			return nil, &frame.BuildError{Kind: frame.ErrNotFound, Name: name}
+		},
+		register: func(name string, concrete any) error {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			if _, ok := concretes[name]; ok {
+				return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: name}
+			}
+			concretes[name] = concrete
+			return nil
+		},
+		unregister: func(name string) {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			delete(concretes, name)
+		},
+		exist: func(name string) bool {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			_, ok := concretes[name]
+			return ok
+		},
+	}
+	c.kinds = append(c.kinds[:c.customKinds:c.customKinds], append([]*kindEntry{entry}, c.kinds[c.customKinds:]...)...)
+	c.customKinds++
+	return nil
+}
+
+// Kinds 按匹配顺序返回所有已注册的kind
+func (c *Constructor) Kinds() []frame.Kind {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	kinds := make([]frame.Kind, 0, len(c.kinds))
+	for _, entry := range c.kinds {
+		kinds = append(kinds, entry.kind)
+	}
+	return kinds
+}
+
+// References 从name的配置文件中按kind声明的ChildFields取出它引用的组件名称，不会构建任何组件
+func (c *Constructor) References(name string) ([]string, error) {
+	confPath, data, constructorType, err := c.readConf(name)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := c.kindOf(constructorType.Type, constructorType.Kind)
+	if err != nil {
+		return nil, nil
+	}
+	children, err := entry.kind.Children(data)
+	if err != nil {
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Type: constructorType.Type, Name: name, Path: confPath, Err: err}
+	}
+	return children, nil
+}
+
+// kindOf 返回类型名称所属的kind，kindName是配置文件中显式声明的kind，为空时按类型名称判断
+// 先按类型名称匹配（与Name相同或Match匹配），能通过Factory解析出类型时再用Interface校验匹配结果，多个kind同时匹配时用Interface区分
+// 类型名称不匹配任何kind时才按实现的Interface判断，此时只能有一个kind的Interface被实现，否则报告ErrAmbiguousKind
+// 通过构造函数注册的类型在创建之前无法得知实际类型，只按类型名称匹配
+func (c *Constructor) kindOf(typeName string, kindName string) (*kindEntry, error) {
+	concreteType, err := c.resolveType(typeName)
+	if err != nil {
+		return nil, err
+	}
+	entries := c.kindEntries()
+	if kindName != "" {
+		for _, entry := range entries {
+			if entry.kind.Name != kindName {
+				continue
+			}
+			if concreteType != nil && entry.kind.Interface != nil && !concreteType.Implements(entry.kind.Interface) {
+				return nil, &frame.BuildError{Kind: frame.ErrNotConcrete, Type: typeName, Err: fmt.Errorf("%s does not implement %s required by kind %s", concreteType, entry.kind.Interface, kindName)}
+			}
+			return entry, nil
+		}
+		return nil, &frame.BuildError{Kind: frame.ErrUnknownType, Type: typeName, Err: fmt.Errorf("kind %s is not registered", kindName)}
+	}
+
+	matched := make([]*kindEntry, 0)
+	for _, entry := range entries {
+		if entry.kind.Matches(typeName) {
+			matched = append(matched, entry)
+		}
+	}
+	if len(matched) > 0 && concreteType == nil {
+		return matched[0], nil
+	}
+	if len(matched) > 0 {
+		implemented := implementedBy(concreteType, matched)
+		switch len(implemented) {
+		case 0:
+			return nil, &frame.BuildError{Kind: frame.ErrNotConcrete, Type: typeName, Err: fmt.Errorf("%s does not implement %s required by kind %s", concreteType, matched[0].kind.Interface, matched[0].kind.Name)}
+		case 1:
+			return implemented[0], nil
+		}
+		return nil, ambiguousKind(typeName, concreteType, implemented)
+	}
+
+	if concreteType == nil {
+		return nil, &frame.BuildError{Kind: frame.ErrUnknownType, Type: typeName, Err: fmt.Errorf("no kind matches type")}
+	}
+	implemented := implementedBy(concreteType, entries)
+	switch len(implemented) {
+	case 0:
+		return nil, &frame.BuildError{Kind: frame.ErrNotConcrete, Type: typeName, Err: fmt.Errorf("%s does not implement the interface of any kind", concreteType)}
+	case 1:
+		return implemented[0], nil
+	}
+	return nil, ambiguousKind(typeName, concreteType, implemented)
+}
+
+// implementedBy 返回concreteType实现了其Interface的kind，没有Interface的kind不参与判断
+func implementedBy(concreteType reflect.Type, entries []*kindEntry) []*kindEntry {
+	implemented := make([]*kindEntry, 0)
+	for _, entry := range entries {
+		if entry.kind.Interface != nil && concreteType.Implements(entry.kind.Interface) {
+			implemented = append(implemented, entry)
+		}
+	}
+	return implemented
+}
+
+func ambiguousKind(typeName string, concreteType reflect.Type, entries []*kindEntry) error {
+	kindsName := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		kindsName = append(kindsName, entry.kind.Name)
+	}
+	return &frame.BuildError{Kind: frame.ErrAmbiguousKind, Type: typeName, Err: fmt.Errorf("%s implements the interfaces of kinds %s, set field kind in the configuration file", concreteType, strings.Join(kindsName, ", "))}
+}
+
+func (c *Constructor) kindEntries() []*kindEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]*kindEntry(nil), c.kinds...)
+}
+
+func interfaceOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// parseConfOf 返回内置kind的ParseConf，校验函数由实现该kind的包通过frame.RegisterConfParser登记，没有登记时只检查yaml格式
+func parseConfOf(kindName string) func(configure []byte) error {
+	return func(configure []byte) error {
+		if parseConf, ok := frame.ConfParser(kindName); ok {
+			return parseConf(configure)
+		}
+		conf := make(map[any]any)
+		return yaml.Unmarshal(configure, &conf)
+	}
+}
+
+func hasSuffix(suffix string) func(string) bool {
+	return func(typeName string) bool {
+		return strings.HasSuffix(typeName, suffix)
+	}
+}
+
+func hasPrefix(prefix string) func(string) bool {
+	return func(typeName string) bool {
+		return strings.HasPrefix(typeName, prefix)
+	}
+}
+
+// registerBuiltinKinds 注册内置kind，通过构造函数注册的类型按名称匹配时取第一个，因此AsyncHandlerGroup要先于HandlerGroup，Handler放在最后
+// HandlerGroup沿用原来按前缀匹配的规则，其余按后缀匹配
+func (c *Constructor) registerBuiltinKinds() {
+	c.kinds = append(c.kinds,
+		&kindEntry{
+			kind: frame.Kind{
+				Name:        TypeNameAsyncHandlerGroup,
+				ParseConf:   parseConfOf(TypeNameAsyncHandlerGroup),
+				Interface:   interfaceOf[frame.AsyncHandlerGroupInterface](),
+				Match:       hasSuffix(TypeNameAsyncHandlerGroup),
+				ChildFields: []string{"handlers"},
+				ChildInterfaces: map[string]reflect.Type{
+					"handlers": interfaceOf[frame.HandlerBaseInterface](),
+				},
+			},
+			get: func(name string) (any, error) {
+				return c.asyncHandlerGroupConstructorInterface.GetAsyncHandlerGroup(name)
+			},
+			register: func(name string, concrete any) error {
+				return c.asyncHandlerGroupConstructorInterface.RegisterAsyncHandlerGroup(name, concrete.(frame.AsyncHandlerGroupBaseInterface))
+			},
+			unregister:         c.unregisterFrom(c.asyncHandlerGroupConstructorInterface),
+			exist:              c.existIn(c.asyncHandlerGroupConstructorInterface),
+			createWithConfPath: c.asyncHandlerGroupConstructorInterface.CreateAsyncHandlerGroupWithConfPath,
+		},
+		&kindEntry{
+			kind: frame.Kind{
+				Name:        TypeNameHandlerGroup,
+				ParseConf:   parseConfOf(TypeNameHandlerGroup),
+				Interface:   interfaceOf[frame.HandlerGroupInterface](),
+				Match:       hasPrefix(TypeNameHandlerGroup),
+				ChildFields: []string{"handlers"},
+				ChildInterfaces: map[string]reflect.Type{
+					"handlers": interfaceOf[frame.HandlerBaseInterface](),
+				},
+			},
+			get: func(name string) (any, error) {
+				return c.handlerGroupConstructorInterface.GetHandlerGroup(name)
+			},
+			register: func(name string, concrete any) error {
+				return c.handlerGroupConstructorInterface.RegisterHandlerGroup(name, concrete.(frame.HandlerGroupBaseInterface))
+			},
+			unregister:         c.unregisterFrom(c.handlerGroupConstructorInterface),
+			exist:              c.existIn(c.handlerGroupConstructorInterface),
+			createWithConfPath: c.handlerGroupConstructorInterface.CreateHandlerGroupWithConfPath,
+		},
+		&kindEntry{
+			kind: frame.Kind{
+				Name:      TypeNameDivider,
+				Interface: interfaceOf[frame.DividerBaseInterface](),
+				Match:     hasSuffix(TypeNameDivider),
+			},
+			get: func(name string) (any, error) {
+				return c.dividerConstructorInterface.GetDivider(name)
+			},
+			register: func(name string, concrete any) error {
+				return c.dividerConstructorInterface.RegisterDivider(name, concrete.(frame.DividerBaseInterface))
+			},
+			unregister:         c.unregisterFrom(c.dividerConstructorInterface),
+			exist:              c.existIn(c.dividerConstructorInterface),
+			createWithConfPath: c.dividerConstructorInterface.CreateDividerWithConfPath,
+		},
+		&kindEntry{
+			kind: frame.Kind{
+				Name:        TypeNameLayer,
+				ParseConf:   parseConfOf(TypeNameLayer),
+				Interface:   interfaceOf[frame.LayerWithBuilderInterface](),
+				Match:       hasSuffix(TypeNameLayer),
+				ChildFields: []string{"divider", "handlers"},
+				ChildInterfaces: map[string]reflect.Type{
+					"divider":  interfaceOf[frame.DividerBaseInterface](),
+					"handlers": interfaceOf[frame.HandlerBaseInterface](),
+				},
+			},
+			get: func(name string) (any, error) {
+				return c.layerConstructorInterface.GetLayer(name)
+			},
+			register: func(name string, concrete any) error {
+				return c.layerConstructorInterface.RegisterLayer(name, concrete.(frame.LayerBaseInterface))
+			},
+			unregister:         c.unregisterFrom(c.layerConstructorInterface),
+			exist:              c.existIn(c.layerConstructorInterface),
+			createWithConfPath: c.layerConstructorInterface.CreateLayerWithConfPath,
+		},
+		&kindEntry{
+			kind: frame.Kind{
+				Name:        TypeNameLayerCenter,
+				ParseConf:   parseConfOf(TypeNameLayerCenter),
+				Interface:   interfaceOf[frame.LayerCenterInterface](),
+				Match:       hasSuffix(TypeNameLayerCenter),
+				ChildFields: []string{"layers", "launch_layers", "domains.layers"},
+				ChildInterfaces: map[string]reflect.Type{
+					"layers":         interfaceOf[frame.LayerBaseInterface](),
+					"launch_layers":  interfaceOf[frame.LayerBaseInterface](),
+					"domains.layers": interfaceOf[frame.LayerBaseInterface](),
+				},
+			},
+			get: func(name string) (any, error) {
+				return c.layerCenterConstructorInterface.GetLayerCenter(name)
+			},
+			register: func(name string, concrete any) error {
+				return c.layerCenterConstructorInterface.RegisterLayerCenter(name, concrete.(frame.LayerCenterBaseInterface))
+			},
+			unregister:         c.unregisterFrom(c.layerCenterConstructorInterface),
+			exist:              c.existIn(c.layerCenterConstructorInterface),
+			createWithConfPath: c.layerCenterConstructorInterface.CreateLayerCenterWithConfPath,
+		},
+		&kindEntry{
+			kind: frame.Kind{
+				Name:      TypeNameHandler,
+				Interface: interfaceOf[frame.HandlerBaseInterface](),
+				Match:     hasSuffix(TypeNameHandler),
+			},
+			get: func(name string) (any, error) {
+				return c.handlerConstructorInterface.GetHandler(name)
+			},
+			register: func(name string, concrete any) error {
+				return c.handlerConstructorInterface.RegisterHandler(name, concrete.(frame.HandlerBaseInterface))
+			},
+			unregister:         c.unregisterFrom(c.handlerConstructorInterface),
+			exist:              c.existIn(c.handlerConstructorInterface),
+			createWithConfPath: c.handlerConstructorInterface.CreateHandlerWithConfPath,
+		},
+	)
+}
+
+func (c *Constructor) unregisterFrom(subConstructor any) func(name string) {
+	return func(name string) {
+		if unregisterInterface, ok := subConstructor.(frame.UnregisterInterface); ok {
+			unregisterInterface.Unregister(name)
+		}
+	}
+}
+
+// existIn 返回判断子构建器中是否已经构建了name的函数，子构建器没有实现frame.ExistInterface时返回nil
+func (c *Constructor) existIn(subConstructor any) func(name string) bool {
+	if existInterface, ok := subConstructor.(frame.ExistInterface); ok {
+		return existInterface.Exist
+	}
+	return nil
+}
+
+// exists 判断组件是否已经构建，没有exist时只能通过get判断，get可能会按配置创建组件
+func (e *kindEntry) exists(name string) bool {
+	if e.exist != nil {
+		return e.exist(name)
+	}
+	_, err := e.get(name)
+	return err == nil
+}
@@ -0,0 +1,224 @@
+package constructor_test
+
+import (
+	"errors"
+	"fmt"
+	"ghgroups/frame"
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+	"ghgroups/frame/utils"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+type testAuctionInterface interface {
+	frame.ConcreteInterface
+	Auction(context *ghgroupscontext.GhGroupsContext) string
+}
+
+type testAuctionConf struct {
+	Name    string   `yaml:"name"`
+	Reserve float64  `yaml:"reserve"`
+	Bidders []string `yaml:"bidders"`
+}
+
+// testAuction 是自定义的组合组件，让所有bidder出价并返回第一个出价的bidder
+type testAuction struct {
+	conf                 testAuctionConf
+	bidders              []frame.HandlerBaseInterface
+	constructorInterface frame.ConstructorInterface
+}
+
+func (t *testAuction) Name() string {
+	return t.conf.Name
+}
+
+func (t *testAuction) SetConstructorInterface(constructorInterface any) {
+	t.constructorInterface = constructorInterface.(frame.ConstructorInterface)
+}
+
+func (t *testAuction) LoadConfigFromMemory(configure []byte) error {
+	if err := yaml.Unmarshal(configure, &t.conf); err != nil {
+		return err
+	}
+	for _, bidder := range t.conf.Bidders {
+		if err := t.constructorInterface.CreateConcrete(bidder); err != nil {
+			return err
+		}
+		concrete, err := t.constructorInterface.GetConcrete(bidder)
+		if err != nil {
+			return err
+		}
+		t.bidders = append(t.bidders, concrete.(frame.HandlerBaseInterface))
+	}
+	return nil
+}
+
+func (t *testAuction) Auction(context *ghgroupscontext.GhGroupsContext) string {
+	context.SetAttribute("calls", []string{})
+	for _, bidder := range t.bidders {
+		if bidder.Handle(context) {
+			return bidder.Name()
+		}
+	}
+	return ""
+}
+
+// testRanker 是类型名称不以Handler结尾的handler，名称不匹配任何kind，按实现的接口归入Handler kind
+type testRanker struct {
+	testNamedHandler
+}
+
+// testCollectHandler 是带有Add方法的普通handler，同时满足HandlerGroup kind的接口，按类型名称归入Handler kind
+type testCollectHandler struct {
+	testNamedHandler
+	added []frame.HandlerBaseInterface
+}
+
+func (t *testCollectHandler) Add(handler frame.HandlerBaseInterface) error {
+	t.added = append(t.added, handler)
+	return nil
+}
+
+// testCollector 与testCollectHandler相同，但类型名称不匹配任何kind
+type testCollector struct {
+	testCollectHandler
+}
+
+type testWrongAuction struct{}
+
+func (t *testWrongAuction) Name() string {
+	return "wrong_auction"
+}
+
+func TestKind(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	assert.Nil(t, errGetWd)
+	confPath := path.Join(runPath, "test_data", "kinds")
+
+	constructor := utils.BuildConstructor(confPath)
+	constructor.Register(reflect.TypeOf(testAuction{}))
+	constructor.Register(reflect.TypeOf(testWrongAuction{}))
+	constructor.Register(reflect.TypeOf(testNamedHandler{}))
+	constructor.Register(reflect.TypeOf(testRanker{}))
+	constructor.Register(reflect.TypeOf(testCollectHandler{}))
+	constructor.Register(reflect.TypeOf(testCollector{}))
+	constructor.RegisterFunc("testFuncHandler", func(configure []byte, constructorInterface frame.ConstructorInterface) (frame.ConcreteInterface, error) {
+		handler := &testNamedHandler{}
+		return handler, handler.LoadConfigFromMemory(configure)
+	})
+
+	auctionKind := frame.Kind{
+		Name:      "Auction",
+		Interface: reflect.TypeOf((*testAuctionInterface)(nil)).Elem(),
+		ParseConf: func(configure []byte) error {
+			conf := testAuctionConf{}
+			if err := yaml.Unmarshal(configure, &conf); err != nil {
+				return err
+			}
+			if conf.Reserve < 0 {
+				return fmt.Errorf("reserve %v must not be negative", conf.Reserve)
+			}
+			return nil
+		},
+		ChildFields: []string{"bidders"},
+	}
+
+	t.Run("Input=register", func(t *testing.T) {
+		assert.Nil(t, constructor.RegisterKind(auctionKind))
+		err := constructor.RegisterKind(auctionKind)
+		assert.True(t, errors.Is(err, frame.ErrDuplicateName))
+
+		kinds := constructor.Kinds()
+		assert.Equal(t, "Auction", kinds[0].Name)
+		assert.Equal(t, 7, len(kinds))
+	})
+
+	t.Run("Input=create", func(t *testing.T) {
+		assert.Nil(t, constructor.CreateConcrete("main_auction"))
+		concrete, err := constructor.GetConcrete("main_auction")
+		assert.Nil(t, err)
+		auction, ok := concrete.(testAuctionInterface)
+		assert.True(t, ok)
+		assert.Equal(t, "bidder_a", auction.Auction(ghgroupscontext.NewGhGroupsContext(nil)))
+		assert.Equal(t, []string{"main_auction"}, constructor.Dependents("bidder_a"))
+	})
+
+	t.Run("Input=references", func(t *testing.T) {
+		children, err := constructor.References("main_auction")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"bidder_a", "bidder_b"}, children)
+	})
+
+	t.Run("Input=parse_conf", func(t *testing.T) {
+		err := constructor.CreateConcrete("bad_auction")
+		assert.True(t, errors.Is(err, frame.ErrLoadConfig))
+		assert.ErrorContains(t, err, "reserve -1 must not be negative")
+		assert.ErrorContains(t, err, "bad_auction.yaml")
+	})
+
+	t.Run("Input=interface", func(t *testing.T) {
+		err := constructor.CreateConcrete("wrong_auction")
+		assert.True(t, errors.Is(err, frame.ErrNotConcrete))
+		assert.ErrorContains(t, err, "wrong_auction.yaml")
+	})
+
+	t.Run("Input=scope", func(t *testing.T) {
+		err := constructor.CreateConcrete("scoped_auction")
+		assert.True(t, errors.Is(err, frame.ErrLoadConfig))
+		assert.ErrorContains(t, err, "field scope")
+		assert.ErrorContains(t, err, "scoped_auction.yaml")
+	})
+
+	t.Run("Input=by_interface", func(t *testing.T) {
+		assert.Nil(t, constructor.CreateConcrete("ranker_a"))
+		handler, err := constructor.GetHandler("ranker_a")
+		assert.Nil(t, err)
+		_, ok := handler.(*testRanker)
+		assert.True(t, ok)
+	})
+
+	t.Run("Input=name_first", func(t *testing.T) {
+		assert.Nil(t, constructor.CreateConcrete("collect_a"))
+		handler, err := constructor.GetHandler("collect_a")
+		assert.Nil(t, err)
+		_, ok := handler.(*testCollectHandler)
+		assert.True(t, ok)
+		_, err = constructor.GetHandlerGroup("collect_a")
+		assert.True(t, errors.Is(err, frame.ErrNotFound))
+	})
+
+	t.Run("Input=ambiguous", func(t *testing.T) {
+		err := constructor.CreateConcrete("collector_a")
+		assert.True(t, errors.Is(err, frame.ErrAmbiguousKind), err)
+		assert.ErrorContains(t, err, "HandlerGroup, Handler")
+
+		// 配置文件中显式声明kind时不再按接口判断
+		assert.Nil(t, constructor.CreateConcrete("collector_b"))
+		handler, err := constructor.GetHandler("collector_b")
+		assert.Nil(t, err)
+		_, ok := handler.(*testCollector)
+		assert.True(t, ok)
+
+		err = constructor.CreateConcrete("collector_wrong_kind")
+		assert.True(t, errors.Is(err, frame.ErrNotConcrete), err)
+	})
+
+	t.Run("Input=func_by_name", func(t *testing.T) {
+		// 通过构造函数注册的类型在创建前无法得知实际类型，按类型名称的后缀归入Handler kind
+		assert.Nil(t, constructor.CreateConcrete("func_bidder"))
+		handler, err := constructor.GetHandler("func_bidder")
+		assert.Nil(t, err)
+		assert.Equal(t, "func_bidder", handler.Name())
+	})
+
+	t.Run("Input=unregister", func(t *testing.T) {
+		assert.Nil(t, constructor.Unregister("main_auction"))
+		_, err := constructor.GetConcrete("main_auction")
+		assert.True(t, errors.Is(err, frame.ErrNotFound))
+	})
+}
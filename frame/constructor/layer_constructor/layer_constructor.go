@@ -6,6 +6,7 @@ import (
 	"os"
 	"path"
 	"reflect"
+	"sort"
 
 	folder "git-codecommit.us-east-1.amazonaws.com/v1/repos/go-utils.git/folder"
 	"gopkg.in/yaml.v2"
@@ -35,15 +36,17 @@ func (l *LayerConstructor) CreateLayerWithConfPath(confFilePath string) error {
 		return errGetlayerName
 	}
 	if path, ok := l.layersConfPath[layerName]; ok {
-		return fmt.Errorf("layer name %s already exists,path is %s", layerName, path)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: layerName, Path: path, Err: fmt.Errorf("layer name %s already exists,path is %s", layerName, path)}
 	}
 	l.layersConfPath[layerName] = confFilePath
 	if l.existLayer(layerName) {
-		return fmt.Errorf("layer %s already exists", layerName)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: layerName, Path: confFilePath, Err: fmt.Errorf("layer %s already exists", layerName)}
 	}
 
 	layerInterface, err := l.constructLayerFromName(layerName)
 	if err != nil {
+		// 构建失败时撤销登记的配置文件路径，之后可以按同一个配置文件重新构建
+		delete(l.layersConfPath, layerName)
 		return err
 	}
 	err = l.RegisterLayer(layerName, layerInterface)
@@ -65,14 +68,19 @@ func (l *LayerConstructor) ParseLayerConfFolder(confFolderPath string) error {
 			return errGetlayerName
 		}
 		if path, ok := l.layersConfPath[layerName]; ok {
-			return fmt.Errorf("layer name %s already exists,path is %s", layerName, path)
+			return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: layerName, Path: path, Err: fmt.Errorf("layer name %s already exists,path is %s", layerName, path)}
 		}
 		l.layersConfPath[layerName] = f
 	}
 
-	for layerName := range l.layersConfPath {
+	names := make([]string, 0, len(l.layersConfPath))
+	for name := range l.layersConfPath {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, layerName := range names {
 		if l.existLayer(layerName) {
-			return fmt.Errorf("layer %s already exists", layerName)
+			return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: layerName, Path: l.layersConfPath[layerName], Err: fmt.Errorf("layer %s already exists", layerName)}
 		}
 
 		layerInterface, err := l.constructLayerFromName(layerName)
@@ -106,7 +114,7 @@ func (l *LayerConstructor) GetLayer(name string) (frame.LayerBaseInterface, erro
 
 func (l *LayerConstructor) RegisterLayer(name string, layer_interface frame.LayerBaseInterface) error {
 	if l.existLayer(name) {
-		return fmt.Errorf("layer %s is exist", name)
+		return &frame.BuildError{Kind: frame.ErrDuplicateName, Name: name, Path: l.layersConfPath[name], Err: fmt.Errorf("layer %s is exist", name)}
 	}
 	l.layers[name] = layer_interface
 	return nil
@@ -117,15 +125,15 @@ func (l *LayerConstructor) RegisterLayer(name string, layer_interface frame.Laye
 func (l *LayerConstructor) constructLayerFromName(layerName string) (frame.LayerWithBuilderInterface, error) {
 	layerConfPath, ok := l.layersConfPath[layerName]
 	if !ok {
-		return nil, fmt.Errorf("%s: layer's configuration is not set", layerName)
+		return nil, &frame.BuildError{Kind: frame.ErrNotFound, Name: layerName, Err: fmt.Errorf("%s: layer's configuration is not set", layerName)}
 	}
 	layerInterface, err := l.constructLayerFromFile(layerConfPath)
 	if err != nil {
-		return nil, err
+		return nil, frame.WithBuildInfo(err, layerName, layerConfPath)
 	}
 
 	if layerInterface.Name() != layerName {
-		return nil, fmt.Errorf("layer Name (%s) mismatch with configuration file Name (%s) path (%s)", layerInterface.Name(), layerName, layerConfPath)
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Type: fmt.Sprintf("%T", layerInterface), Name: layerName, Path: layerConfPath, Err: fmt.Errorf("layer Name (%s) mismatch with configuration file Name (%s) path (%s)", layerInterface.Name(), layerName, layerConfPath)}
 	}
 	return layerInterface, err
 }
@@ -133,12 +141,12 @@ func (l *LayerConstructor) constructLayerFromName(layerName string) (frame.Layer
 func (l *LayerConstructor) constructLayerFromFile(filePath string) (frame.LayerWithBuilderInterface, error) {
 	configure, errReadFile := os.ReadFile(filePath)
 	if errReadFile != nil {
-		return nil, errReadFile
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: errReadFile}
 	}
 	layerConf := make(map[any]any)
 	errReadFile = yaml.Unmarshal([]byte(configure), &layerConf)
 	if errReadFile != nil {
-		return nil, errReadFile
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: errReadFile}
 	}
 	return l.constructLayer(layerConf)
 }
@@ -152,27 +160,30 @@ func (l *LayerConstructor) constructLayer(layer_conf map[any]any) (frame.LayerWi
 				typeName := v.(string)
 
 				layer, err := l.constructorInterface.Create(typeName, originConf, l.constructorInterface)
-				layerInterface := layer.(frame.LayerWithBuilderInterface)
-				// layerInterface.SetConstructorInterface(l.constructorInterface)
-				// layerInterface.LoadEnvironmentConf(env, region, l.constructorInterface)
 				if err != nil {
 					return nil, err
 				}
+				layerInterface, ok := layer.(frame.LayerWithBuilderInterface)
+				if !ok {
+					return nil, &frame.BuildError{Kind: frame.ErrNotConcrete, Type: typeName, Err: fmt.Errorf("convert %s to LayerWithBuilderInterface error", typeName)}
+				}
+				// layerInterface.SetConstructorInterface(l.constructorInterface)
+				// layerInterface.LoadEnvironmentConf(env, region, l.constructorInterface)
 
 				return layerInterface, nil
 			}
 		}
 	}
-	return nil, fmt.Errorf("layer configuration must have type %v", layer_conf)
+	return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Err: fmt.Errorf("layer configuration must have type %v", layer_conf)}
 }
 
 func (l *LayerConstructor) getLayerName(filePath string) (string, error) {
 	s, err := os.Stat(filePath)
 	if err != nil {
-		return "", err
+		return "", &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: err}
 	}
 	if s.IsDir() {
-		return "", fmt.Errorf("file path %s is not a directory", filePath)
+		return "", &frame.BuildError{Kind: frame.ErrLoadConfig, Path: filePath, Err: fmt.Errorf("file path %s is not a directory", filePath)}
 	}
 
 	fileNameWithExt := path.Base(filePath)
@@ -186,6 +197,18 @@ func (l *LayerConstructor) existLayer(name string) bool {
 	return exist
 }
 
+// Exist 判断组件是否已经构建，不会按配置创建
+func (l *LayerConstructor) Exist(name string) bool {
+	return l.existLayer(name)
+}
+
+// Unregister 删除已经构建的layer，之后再次获取时按配置重新构建
+func (l *LayerConstructor) Unregister(name string) bool {
+	_, exist := l.layers[name]
+	delete(l.layers, name)
+	return exist
+}
+
 func (l *LayerConstructor) SetConstructorInterface(constructorInterface any) {
 	constructorInterfaceNew, ok := constructorInterface.(frame.ConstructorInterface)
 	if !ok {
@@ -97,7 +97,7 @@ func TestInit(t *testing.T) {
 	factory.Register(reflect.TypeOf(layercenterconstructor.LayerCenterConstructor{}))
 	factory.Register(reflect.TypeOf(handlergroupconstructor.HandlerGroupConstructor{}))
 	factory.Register(reflect.TypeOf(aynchandlergroupconstructor.AsyncHandlerGroupConstructor{}))
-	constructor := constructor.NewConstructor(factory, "")
+	constructor := constructor.MustNewConstructor(factory, "")
 	constructor.Register(reflect.TypeOf(layer.Layer{}))
 
 	constructor.Register(reflect.TypeOf(sampledivider.SampleAutoConstructDivider{}))
@@ -195,7 +195,7 @@ func TestConstructLayerFromFile(t *testing.T) {
 	factory.Register(reflect.TypeOf(layercenterconstructor.LayerCenterConstructor{}))
 	factory.Register(reflect.TypeOf(handlergroupconstructor.HandlerGroupConstructor{}))
 	factory.Register(reflect.TypeOf(aynchandlergroupconstructor.AsyncHandlerGroupConstructor{}))
-	constructor := constructor.NewConstructor(factory, "")
+	constructor := constructor.MustNewConstructor(factory, "")
 
 	constructor.Register(reflect.TypeOf(layer.Layer{}))
 	constructor.Register(reflect.TypeOf(sampledivider.SampleAutoConstructDivider{}))
@@ -245,7 +245,7 @@ func TestConstructLayerFromName(t *testing.T) {
 	factory.Register(reflect.TypeOf(layercenterconstructor.LayerCenterConstructor{}))
 	factory.Register(reflect.TypeOf(handlergroupconstructor.HandlerGroupConstructor{}))
 	factory.Register(reflect.TypeOf(aynchandlergroupconstructor.AsyncHandlerGroupConstructor{}))
-	constructor := constructor.NewConstructor(factory, "")
+	constructor := constructor.MustNewConstructor(factory, "")
 
 	constructor.Register(reflect.TypeOf(layer.Layer{}))
 	constructor.Register(reflect.TypeOf(sampledivider.SampleAutoConstructDivider{}))
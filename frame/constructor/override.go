@@ -0,0 +1,173 @@
+package constructor
+
+import (
+	"fmt"
+	"ghgroups/frame"
+	"strings"
+)
+
+// Override 用concrete替换名为name的组件，常用于在集成测试中把handler换成假实现
+// 构建之前调用时，之后所有引用name的组合组件都会拿到concrete
+// 构建之后调用时，已经持有旧实例的组合组件通过frame.RebinderInterface重新绑定，
+// 不支持重新绑定的组合组件会以ErrRebuildRequired返回，需要调用方重新构建它们
+func (c *Constructor) Override(name string, concrete frame.ConcreteInterface) error {
+	if concrete == nil {
+		return &frame.BuildError{Kind: frame.ErrNotConcrete, Name: name}
+	}
+	if concrete.Name() != name {
+		return fmt.Errorf("override Name (%s) mismatch with Name (%s)", concrete.Name(), name)
+	}
+	// 先按name所属的kind检查类型，不符合时不会写入Override，也不会改动任何组合组件
+	typeName, kindName := name, ""
+	if _, _, constructorType, err := c.readConf(name); err == nil {
+		typeName, kindName = constructorType.Type, constructorType.Kind
+	}
+	if entry, err := c.kindOf(typeName, kindName); err == nil && !entry.kind.Implements(concrete) {
+		return &frame.BuildError{Kind: frame.ErrNotConcrete, Type: typeName, Name: name, Err: fmt.Errorf("override %T does not implement %s required by kind %s", concrete, entry.kind.Interface, entry.kind.Name)}
+	}
+
+	c.mutex.Lock()
+	previous, overridden := c.overrides[name]
+	c.mutex.Unlock()
+	if !overridden && c.existConcrete(name) {
+		previous, _ = c.lookupConcrete(name)
+	}
+
+	c.mutex.Lock()
+	c.overrides[name] = concrete
+	built := append([]any(nil), c.built...)
+	c.mutex.Unlock()
+
+	rebound := make(map[string]bool)
+	touched := make([]frame.RebinderInterface, 0)
+	for _, builtConcrete := range built {
+		rebinderInterface, ok := builtConcrete.(frame.RebinderInterface)
+		if !ok {
+			continue
+		}
+		touched = append(touched, rebinderInterface)
+		ok, err := rebinderInterface.Rebind(name, concrete)
+		if err != nil {
+			c.rollbackOverride(name, previous, overridden, touched)
+			return err
+		}
+		if concreteInterface, named := builtConcrete.(frame.ConcreteInterface); ok && named {
+			rebound[concreteInterface.Name()] = true
+		}
+	}
+
+	stale := make([]string, 0)
+	for _, parent := range c.dependentsOf(name) {
+		if !rebound[parent] {
+			stale = append(stale, parent)
+		}
+	}
+	if len(stale) > 0 {
+		return &frame.BuildError{Kind: frame.ErrRebuildRequired, Name: name, Err: fmt.Errorf("referenced by %s", strings.Join(stale, ", "))}
+	}
+	return nil
+}
+
+// rollbackOverride 在Rebind失败时恢复原来的Override，并把已经重新绑定的组合组件绑回原来的组件
+func (c *Constructor) rollbackOverride(name string, previous any, overridden bool, touched []frame.RebinderInterface) {
+	c.mutex.Lock()
+	if overridden {
+		c.overrides[name] = previous
+	} else {
+		delete(c.overrides, name)
+	}
+	c.mutex.Unlock()
+
+	if previous == nil {
+		return
+	}
+	for _, rebinderInterface := range touched {
+		rebinderInterface.Rebind(name, previous)
+	}
+}
+
+// overrideOf 返回name的Override，Override没有实现T时返回ErrNotConcrete
+func overrideOf[T any](c *Constructor, name string) (T, bool, error) {
+	var typed T
+	c.mutex.Lock()
+	override, ok := c.overrides[name]
+	c.mutex.Unlock()
+	if !ok {
+		return typed, false, nil
+	}
+	typed, ok = override.(T)
+	if !ok {
+		return typed, true, &frame.BuildError{Kind: frame.ErrNotConcrete, Name: name, Err: fmt.Errorf("override %T is not %s", override, interfaceOf[T]())}
+	}
+	return typed, true, nil
+}
+
+// Unregister 删除名为name的组件和它的Override，之后再引用name时会按配置重新构建
+// 已经构建好的组合组件仍然持有它时返回ErrRebuildRequired，需要调用方重新构建这些组合组件
+func (c *Constructor) Unregister(name string) error {
+	c.mutex.Lock()
+	delete(c.overrides, name)
+	delete(c.references, name)
+	c.mutex.Unlock()
+
+	for _, entry := range c.kindEntries() {
+		entry.unregister(name)
+	}
+	c.factoryInterface.Remove(name)
+
+	// name不再引用任何组件
+	c.removeParent(name)
+
+	if parents := c.dependentsOf(name); len(parents) > 0 {
+		return &frame.BuildError{Kind: frame.ErrRebuildRequired, Name: name, Err: fmt.Errorf("referenced by %s", strings.Join(parents, ", "))}
+	}
+	return nil
+}
+
+// Dependents 返回构建过程中引用了name的组件名称
+func (c *Constructor) Dependents(name string) []string {
+	return c.dependentsOf(name)
+}
+
+func (c *Constructor) addDependent(name string, parent string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, exist := range c.dependents[name] {
+		if exist == parent {
+			return false
+		}
+	}
+	c.dependents[name] = append(c.dependents[name], parent)
+	return true
+}
+
+func (c *Constructor) removeDependent(name string, parent string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.dependents[name] = remove(c.dependents[name], parent)
+}
+
+// removeParent 删除parent对其他组件的所有引用
+func (c *Constructor) removeParent(parent string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for child, parents := range c.dependents {
+		c.dependents[child] = remove(parents, parent)
+	}
+}
+
+func (c *Constructor) dependentsOf(name string) []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]string(nil), c.dependents[name]...)
+}
+
+func remove(names []string, name string) []string {
+	kept := names[:0]
+	for _, n := range names {
+		if n != name {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
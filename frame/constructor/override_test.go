@@ -0,0 +1,180 @@
+package constructor_test
+
+import (
+	"errors"
+	"ghgroups/frame"
+	"ghgroups/frame/constructor"
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+	handlergroup "ghgroups/frame/handler_group"
+	"ghgroups/frame/layer"
+	"ghgroups/frame/utils"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+type testNamedHandler struct {
+	name string
+}
+
+func (t *testNamedHandler) Name() string {
+	return t.name
+}
+
+func (t *testNamedHandler) LoadConfigFromMemory(configure []byte) error {
+	conf := struct {
+		Name string `yaml:"name"`
+	}{}
+	if err := yaml.Unmarshal(configure, &conf); err != nil {
+		return err
+	}
+	t.name = conf.Name
+	return nil
+}
+
+func (t *testNamedHandler) Handle(context *ghgroupscontext.GhGroupsContext) bool {
+	calls, _ := context.GetAttribute("calls")
+	context.SetAttribute("calls", append(calls.([]string), t.name))
+	return true
+}
+
+type testFakeHandler struct {
+	testNamedHandler
+}
+
+func (t *testFakeHandler) Handle(context *ghgroupscontext.GhGroupsContext) bool {
+	calls, _ := context.GetAttribute("calls")
+	context.SetAttribute("calls", append(calls.([]string), "fake_"+t.name))
+	return true
+}
+
+// testNamedConcrete 只实现了ConcreteInterface，不能替换handler
+type testNamedConcrete struct {
+	name string
+}
+
+func (t *testNamedConcrete) Name() string {
+	return t.name
+}
+
+type testFixedDivider struct{}
+
+func (t *testFixedDivider) Name() string {
+	return "fixed_divider"
+}
+
+func (t *testFixedDivider) Select(context *ghgroupscontext.GhGroupsContext) string {
+	return "handler_a"
+}
+
+func newOverrideConstructor(t *testing.T) *constructor.Constructor {
+	runPath, errGetWd := os.Getwd()
+	assert.Nil(t, errGetWd)
+	constructor := utils.BuildConstructor(path.Join(runPath, "test_data", "overrides"))
+	constructor.Register(reflect.TypeOf(handlergroup.HandlerGroup{}))
+	constructor.Register(reflect.TypeOf(layer.Layer{}))
+	constructor.Register(reflect.TypeOf(testNamedHandler{}))
+	constructor.Register(reflect.TypeOf(testFixedDivider{}))
+	return constructor
+}
+
+func handleMain(t *testing.T, constructor *constructor.Constructor) []string {
+	concrete, err := constructor.GetConcrete("group_main")
+	assert.Nil(t, err)
+	ctx := ghgroupscontext.NewGhGroupsContext(nil)
+	ctx.SetAttribute("calls", []string{})
+	assert.True(t, concrete.(frame.HandlerBaseInterface).Handle(ctx))
+	calls, _ := ctx.GetAttribute("calls")
+	return calls.([]string)
+}
+
+func TestOverride(t *testing.T) {
+	t.Run("Input=before_build", func(t *testing.T) {
+		constructor := newOverrideConstructor(t)
+		fake := &testFakeHandler{testNamedHandler{name: "handler_a"}}
+		assert.Nil(t, constructor.Override("handler_a", fake))
+		assert.Nil(t, constructor.CreateConcrete("group_main"))
+		assert.Equal(t, []string{"fake_handler_a", "fake_handler_a"}, handleMain(t, constructor))
+	})
+
+	t.Run("Input=after_build", func(t *testing.T) {
+		constructor := newOverrideConstructor(t)
+		assert.Nil(t, constructor.CreateConcrete("group_main"))
+		assert.Equal(t, []string{"handler_a", "handler_a"}, handleMain(t, constructor))
+		assert.ElementsMatch(t, []string{"group_main", "layer_x"}, constructor.Dependents("handler_a"))
+
+		fake := &testFakeHandler{testNamedHandler{name: "handler_a"}}
+		assert.Nil(t, constructor.Override("handler_a", fake))
+		assert.Equal(t, []string{"fake_handler_a", "fake_handler_a"}, handleMain(t, constructor))
+
+		err := constructor.Override("handler_a", &testNamedHandler{name: "handler_c"})
+		assert.ErrorContains(t, err, "mismatch")
+	})
+
+	t.Run("Input=wrong_type", func(t *testing.T) {
+		constructor := newOverrideConstructor(t)
+		assert.Nil(t, constructor.CreateConcrete("group_main"))
+
+		err := constructor.Override("handler_a", &testNamedConcrete{name: "handler_a"})
+		assert.True(t, errors.Is(err, frame.ErrNotConcrete))
+		concrete, err := constructor.GetConcrete("handler_a")
+		assert.Nil(t, err)
+		_, ok := concrete.(*testNamedHandler)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"handler_a", "handler_a"}, handleMain(t, constructor))
+	})
+
+	t.Run("Input=typed_getters", func(t *testing.T) {
+		constructor := newOverrideConstructor(t)
+		fake := &testFakeHandler{testNamedHandler{name: "handler_a"}}
+		assert.Nil(t, constructor.Override("handler_a", fake))
+
+		handler, err := constructor.GetHandler("handler_a")
+		assert.Nil(t, err)
+		assert.Same(t, fake, handler)
+		concrete, err := constructor.Get("handler_a")
+		assert.Nil(t, err)
+		assert.Same(t, fake, concrete)
+
+		_, err = constructor.GetDivider("handler_a")
+		assert.True(t, errors.Is(err, frame.ErrNotConcrete))
+	})
+
+	t.Run("Input=override_twice", func(t *testing.T) {
+		constructor := newOverrideConstructor(t)
+		assert.Nil(t, constructor.CreateConcrete("group_main"))
+
+		first := &testFakeHandler{testNamedHandler{name: "handler_a"}}
+		assert.Nil(t, constructor.Override("handler_a", first))
+		// 组合组件按引用的名称重新绑定，与已经持有的组件当前的Name无关
+		first.name = "renamed"
+		second := &testFakeHandler{testNamedHandler{name: "handler_a"}}
+		assert.Nil(t, constructor.Override("handler_a", second))
+		assert.Equal(t, []string{"fake_handler_a", "fake_handler_a"}, handleMain(t, constructor))
+
+		err := constructor.Unregister("handler_a")
+		assert.True(t, errors.Is(err, frame.ErrRebuildRequired))
+		assert.ErrorContains(t, err, "referenced by group_main, layer_x")
+	})
+
+	t.Run("Input=unregister", func(t *testing.T) {
+		constructor := newOverrideConstructor(t)
+		fake := &testFakeHandler{testNamedHandler{name: "handler_a"}}
+		assert.Nil(t, constructor.Override("handler_a", fake))
+		assert.Nil(t, constructor.CreateConcrete("group_main"))
+
+		// 组合组件仍然持有handler_a，需要重新构建
+		err := constructor.Unregister("handler_a")
+		assert.True(t, errors.Is(err, frame.ErrRebuildRequired))
+		assert.ErrorContains(t, err, "referenced by group_main, layer_x")
+
+		assert.Nil(t, constructor.Unregister("group_main"))
+		assert.Nil(t, constructor.Unregister("layer_x"))
+		assert.Nil(t, constructor.CreateConcrete("group_main"))
+		assert.Equal(t, []string{"handler_a", "handler_a"}, handleMain(t, constructor))
+	})
+}
@@ -0,0 +1,193 @@
+package constructor
+
+import (
+	"fmt"
+	"ghgroups/frame"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// KindResource 配置文件中kind为resource的组件是共享资源，由Constructor统一创建、注入和关闭
+const KindResource = "resource"
+
+// 组件通过结构体tag声明需要注入的资源，例如 Cache *RedisClient `ghgroups:"resource=user_profile_cache"`
+const resourceTagKey = "ghgroups"
+const resourceTagPrefix = "resource="
+
+// Resource 按名称返回共享资源，第一次使用时根据配置文件创建
+// 并发的第一次使用只会创建一个实例；资源之间通过tag互相注入形成循环时返回ErrCycle
+func (c *Constructor) Resource(name string) (any, error) {
+	return c.resource(name, nil)
+}
+
+// resourceBuilder 是创建资源时交给Factory的ConstructorInterface，记录正在创建的资源链
+// 资源在创建过程中注入的其他资源沿着这条链创建，不需要再次加锁，并据此发现资源之间的循环引用
+type resourceBuilder struct {
+	*Constructor
+	stack []string
+}
+
+func (r *resourceBuilder) Resource(name string) (any, error) {
+	return r.Constructor.resource(name, r.stack)
+}
+
+func (r *resourceBuilder) Inject(concrete any) error {
+	return r.Constructor.inject(concrete, r.stack)
+}
+
+func (c *Constructor) cachedResource(name string) (any, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	resource, ok := c.resources[name]
+	return resource, ok
+}
+
+// resource 创建资源，stack为正在创建的资源链，为空时说明是最外层的创建，需要持有resourceMutex
+func (c *Constructor) resource(name string, stack []string) (any, error) {
+	if resource, ok := c.cachedResource(name); ok {
+		return resource, nil
+	}
+	if len(stack) == 0 {
+		c.resourceMutex.Lock()
+		defer c.resourceMutex.Unlock()
+		// 等待锁期间其他goroutine可能已经创建了该资源
+		if resource, ok := c.cachedResource(name); ok {
+			return resource, nil
+		}
+	}
+	for i, creating := range stack {
+		if creating == name {
+			confPath, _ := c.concreteConfManager.GetConfPath(name)
+			cycle := append(append([]string(nil), stack[i:]...), name)
+			return nil, &frame.BuildError{Kind: frame.ErrCycle, Name: name, Path: confPath, Err: fmt.Errorf("%s", c.cyclePath(cycle))}
+		}
+	}
+
+	confPath, err := c.concreteConfManager.GetConfPath(name)
+	if err != nil {
+		return nil, &frame.BuildError{Kind: frame.ErrMissingResource, Name: name}
+	}
+	data, err := os.ReadFile(confPath)
+	if err != nil {
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Name: name, Path: confPath, Err: err}
+	}
+	var constructorType ConstructorType
+	if err := yaml.Unmarshal(data, &constructorType); err != nil {
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Name: name, Path: confPath, Err: err}
+	}
+	if constructorType.Kind != KindResource {
+		return nil, &frame.BuildError{Kind: frame.ErrMissingResource, Name: name, Path: confPath, Err: fmt.Errorf("kind is %q, not %s", constructorType.Kind, KindResource)}
+	}
+
+	// 资源不经过c.Create，不会被当作普通组件记录，Close时在所有组件之后关闭
+	builder := &resourceBuilder{Constructor: c, stack: append(append([]string(nil), stack...), name)}
+	concrete, err := c.factoryInterface.Create(constructorType.Type, data, builder)
+	if err != nil {
+		return nil, frame.WithBuildInfo(err, name, confPath)
+	}
+	if concreteInterface, ok := concrete.(frame.ConcreteInterface); ok && concreteInterface.Name() != name {
+		// 创建出的资源不会被使用，释放名称并关闭它
+		c.factoryInterface.Remove(concreteInterface.Name())
+		if closer, ok := concrete.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Type: constructorType.Type, Name: name, Path: confPath, Err: fmt.Errorf("resource Name (%s) mismatch with configuration file Name (%s) path (%s)", concreteInterface.Name(), name, confPath)}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.resources[name] = concrete
+	c.resourceNames = append(c.resourceNames, name)
+	return concrete, nil
+}
+
+// Inject 为组件中带有ghgroups:"resource=名称"tag的导出字段注入资源，资源不存在或类型不匹配时返回错误
+func (c *Constructor) Inject(concrete any) error {
+	return c.inject(concrete, nil)
+}
+
+func (c *Constructor) inject(concrete any, stack []string) error {
+	value := reflect.ValueOf(concrete)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	value = value.Elem()
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		resourceName, ok := resourceTag(field)
+		if !ok {
+			continue
+		}
+		if !field.IsExported() {
+			return fmt.Errorf("field %s.%s must be exported to receive resource %s", valueType.Name(), field.Name, resourceName)
+		}
+		resource, err := c.resource(resourceName, stack)
+		if err != nil {
+			return err
+		}
+		resourceValue := reflect.ValueOf(resource)
+		if !resourceValue.Type().AssignableTo(field.Type) {
+			return fmt.Errorf("resource %s of type %s is not assignable to field %s.%s of type %s", resourceName, resourceValue.Type(), valueType.Name(), field.Name, field.Type)
+		}
+		value.Field(i).Set(resourceValue)
+	}
+	return nil
+}
+
+func resourceTag(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup(resourceTagKey)
+	if !ok {
+		return "", false
+	}
+	for _, option := range strings.Split(tag, ",") {
+		option = strings.TrimSpace(option)
+		if strings.HasPrefix(option, resourceTagPrefix) && len(option) > len(resourceTagPrefix) {
+			return strings.TrimPrefix(option, resourceTagPrefix), true
+		}
+	}
+	return "", false
+}
+
+// Close 先按创建的逆序关闭实现了io.Closer的组件，再按创建的逆序关闭资源，保证资源在依赖它的组件之后关闭
+func (c *Constructor) Close() error {
+	c.mutex.Lock()
+	closers := make([]io.Closer, 0, len(c.closers)+len(c.resourceNames))
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		closers = append(closers, c.closers[i])
+	}
+	for i := len(c.resourceNames) - 1; i >= 0; i-- {
+		if closer, ok := c.resources[c.resourceNames[i]].(io.Closer); ok {
+			closers = append(closers, closer)
+		}
+	}
+	c.closers = nil
+	c.resources = make(map[string]any)
+	c.resourceNames = nil
+	c.mutex.Unlock()
+
+	var errs []string
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("close error: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// track 记录通过Create创建的组件，Override时在其中查找需要重新绑定的组合组件，Close时关闭其中实现了io.Closer的组件
+func (c *Constructor) track(concrete any) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.built = append(c.built, concrete)
+	if closer, ok := concrete.(io.Closer); ok {
+		c.closers = append(c.closers, closer)
+	}
+}
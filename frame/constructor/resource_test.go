@@ -0,0 +1,216 @@
+package constructor_test
+
+import (
+	"errors"
+	"ghgroups/frame"
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+	handlergroup "ghgroups/frame/handler_group"
+	"ghgroups/frame/utils"
+	"os"
+	"path"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+var closed []string
+
+type testCacheResource struct {
+	name string
+}
+
+func (t *testCacheResource) Name() string {
+	return t.name
+}
+
+func (t *testCacheResource) LoadConfigFromMemory(configure []byte) error {
+	conf := struct {
+		Name string `yaml:"name"`
+	}{}
+	if err := yaml.Unmarshal(configure, &conf); err != nil {
+		return err
+	}
+	t.name = conf.Name
+	return nil
+}
+
+func (t *testCacheResource) Close() error {
+	closed = append(closed, t.name)
+	return nil
+}
+
+type testProfileHandler struct {
+	Cache       *testCacheResource `ghgroups:"resource=user_profile_cache"`
+	cacheInLoad *testCacheResource
+}
+
+func (t *testProfileHandler) Name() string {
+	return "profile_handler"
+}
+
+func (t *testProfileHandler) LoadConfigFromMemory(configure []byte) error {
+	t.cacheInLoad = t.Cache
+	return nil
+}
+
+func (t *testProfileHandler) Handle(context *ghgroupscontext.GhGroupsContext) bool {
+	context.SetAttribute("cache", t.Cache.Name())
+	return true
+}
+
+func (t *testProfileHandler) Close() error {
+	closed = append(closed, t.Name())
+	return nil
+}
+
+type testBrokenHandler struct {
+	Cache *testCacheResource `ghgroups:"resource=not_exist_cache"`
+}
+
+func (t *testBrokenHandler) Name() string {
+	return "broken_handler"
+}
+
+func (t *testBrokenHandler) Handle(context *ghgroupscontext.GhGroupsContext) bool {
+	return true
+}
+
+// testFuncProfileHandler 通过构造函数创建，资源在构造函数返回后注入
+type testFuncProfileHandler struct {
+	Cache *testCacheResource `ghgroups:"resource=user_profile_cache"`
+}
+
+func (t *testFuncProfileHandler) Name() string {
+	return "func_profile_handler"
+}
+
+func (t *testFuncProfileHandler) Handle(context *ghgroupscontext.GhGroupsContext) bool {
+	return true
+}
+
+// testLoopResourceA和testLoopResourceB互相注入，形成资源之间的循环
+type testLoopResourceA struct {
+	Peer *testLoopResourceB `ghgroups:"resource=loop_b"`
+}
+
+func (t *testLoopResourceA) Name() string {
+	return "loop_a"
+}
+
+type testLoopResourceB struct {
+	Peer *testLoopResourceA `ghgroups:"resource=loop_a"`
+}
+
+func (t *testLoopResourceB) Name() string {
+	return "loop_b"
+}
+
+func TestResource(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	assert.Nil(t, errGetWd)
+	confPath := path.Join(runPath, "test_data", "resources")
+
+	constructor := utils.BuildConstructor(confPath)
+	constructor.Register(reflect.TypeOf(handlergroup.HandlerGroup{}))
+	constructor.Register(reflect.TypeOf(testCacheResource{}))
+	constructor.Register(reflect.TypeOf(testProfileHandler{}))
+	constructor.Register(reflect.TypeOf(testBrokenHandler{}))
+	constructor.RegisterFunc("testFuncProfileHandler", func([]byte, frame.ConstructorInterface) (frame.ConcreteInterface, error) {
+		return &testFuncProfileHandler{}, nil
+	})
+
+	t.Run("Input=inject", func(t *testing.T) {
+		assert.Nil(t, constructor.CreateConcrete("profile_group"))
+		concrete, err := constructor.GetConcrete("profile_handler")
+		assert.Nil(t, err)
+		handler := concrete.(*testProfileHandler)
+
+		resource, err := constructor.Resource("user_profile_cache")
+		assert.Nil(t, err)
+		assert.Same(t, resource, handler.Cache)
+		assert.Same(t, resource, handler.cacheInLoad)
+
+		group, err := constructor.GetConcrete("profile_group")
+		assert.Nil(t, err)
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		assert.True(t, group.(frame.HandlerBaseInterface).Handle(ctx))
+		value, _ := ctx.GetAttribute("cache")
+		assert.Equal(t, "user_profile_cache", value)
+	})
+
+	t.Run("Input=inject_func", func(t *testing.T) {
+		assert.Nil(t, constructor.CreateConcrete("func_profile_handler"))
+		concrete, err := constructor.GetConcrete("func_profile_handler")
+		assert.Nil(t, err)
+
+		resource, err := constructor.Resource("user_profile_cache")
+		assert.Nil(t, err)
+		assert.Same(t, resource, concrete.(*testFuncProfileHandler).Cache)
+	})
+
+	t.Run("Input=missing", func(t *testing.T) {
+		err := constructor.CreateConcrete("broken_handler")
+		assert.True(t, errors.Is(err, frame.ErrInjectResource))
+		assert.True(t, errors.Is(err, frame.ErrMissingResource))
+		assert.ErrorContains(t, err, "name=not_exist_cache")
+
+		_, err = constructor.Resource("profile_handler")
+		assert.True(t, errors.Is(err, frame.ErrMissingResource))
+	})
+
+	t.Run("Input=close", func(t *testing.T) {
+		closed = nil
+		assert.Nil(t, constructor.Close())
+		assert.Equal(t, []string{"profile_handler", "user_profile_cache"}, closed)
+	})
+}
+
+func TestResourceCreation(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	assert.Nil(t, errGetWd)
+	confPath := path.Join(runPath, "test_data", "resources")
+
+	constructor := utils.BuildConstructor(confPath)
+	constructor.Register(reflect.TypeOf(testCacheResource{}))
+	constructor.Register(reflect.TypeOf(testLoopResourceA{}))
+	constructor.Register(reflect.TypeOf(testLoopResourceB{}))
+
+	t.Run("Input=concurrent", func(t *testing.T) {
+		resources := make([]any, 8)
+		wg := sync.WaitGroup{}
+		for i := range resources {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				resources[i], _ = constructor.Resource("user_profile_cache")
+			}(i)
+		}
+		wg.Wait()
+		assert.NotNil(t, resources[0])
+		for _, resource := range resources {
+			assert.Same(t, resources[0], resource)
+		}
+	})
+
+	t.Run("Input=cycle", func(t *testing.T) {
+		_, err := constructor.Resource("loop_a")
+		assert.True(t, errors.Is(err, frame.ErrCycle), err)
+		assert.ErrorContains(t, err, "loop_a")
+		assert.ErrorContains(t, err, "loop_b")
+	})
+
+	t.Run("Input=mismatched_name", func(t *testing.T) {
+		closed = nil
+		_, err := constructor.Resource("mismatched_cache")
+		assert.True(t, errors.Is(err, frame.ErrLoadConfig))
+		assert.ErrorContains(t, err, "mismatch")
+		assert.Equal(t, []string{"other_cache"}, closed)
+
+		// 名称已经释放，再次获取时报告同样的错误
+		_, err = constructor.Resource("mismatched_cache")
+		assert.ErrorContains(t, err, "mismatch")
+	})
+}
@@ -0,0 +1,273 @@
+package constructor
+
+import (
+	"fmt"
+	"ghgroups/frame"
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v2"
+)
+
+// validatedConcrete 记录Validate检查过的组件，concreteType未知时为nil，只有叶子组件会创建临时的concrete
+type validatedConcrete struct {
+	concreteType reflect.Type
+	concrete     any
+}
+
+type validation struct {
+	constructor *Constructor
+	problems    []*frame.BuildError
+	visited     map[string]*validatedConcrete
+	stack       []string
+}
+
+// Validate 从root开始检查整张组件图：解析每个配置、确认每个引用和类型都存在、检查子组件的kind和divider的输出
+// 只有没有子组件的叶子组件（handler、divider等）会临时创建以加载配置，创建后即丢弃，组合组件不会创建，也不会调用任何Handle
+// 发现的所有问题汇总在*frame.ValidationError中返回，适合在CI中检查配置目录
+func (c *Constructor) Validate(root string) error {
+	c.mutex.Lock()
+	resourceCount := len(c.resourceNames)
+	c.mutex.Unlock()
+
+	v := &validation{
+		constructor: c,
+		visited:     make(map[string]*validatedConcrete),
+	}
+	v.validate(root)
+	v.release()
+	c.releaseResources(resourceCount)
+
+	if len(v.problems) > 0 {
+		return &frame.ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+func (v *validation) report(err error, kind error, typeName string, name string, confPath string) {
+	err = frame.WithBuildInfo(err, name, confPath)
+	if buildError, ok := err.(*frame.BuildError); ok {
+		if buildError.Type == "" {
+			buildError.Type = typeName
+		}
+		v.problems = append(v.problems, buildError)
+		return
+	}
+	v.problems = append(v.problems, &frame.BuildError{Kind: kind, Type: typeName, Name: name, Path: confPath, Err: err})
+}
+
+func (v *validation) validate(name string) *validatedConcrete {
+	c := v.constructor
+	for i, building := range v.stack {
+		if building == name {
+			confPath, _ := c.concreteConfManager.GetConfPath(name)
+			cycle := append(append([]string(nil), v.stack[i:]...), name)
+			v.report(fmt.Errorf("%s", c.cyclePath(cycle)), frame.ErrCycle, "", name, confPath)
+			return nil
+		}
+	}
+	if validated, ok := v.visited[name]; ok {
+		return validated
+	}
+	validated := &validatedConcrete{}
+	v.visited[name] = validated
+
+	c.mutex.Lock()
+	override, ok := c.overrides[name]
+	c.mutex.Unlock()
+	if ok {
+		validated.concreteType = reflect.TypeOf(override)
+		return validated
+	}
+
+	// 没有配置文件的名称就是类型名称，与CreateConcrete相同
+	typeName, confPath, data := name, "", []byte{}
+	var constructorType ConstructorType
+	if _, err := c.concreteConfManager.GetConfPath(name); err == nil {
+		var errRead error
+		confPath, data, constructorType, errRead = c.readConf(name)
+		if errRead != nil {
+			v.report(errRead, frame.ErrLoadConfig, "", name, confPath)
+			return validated
+		}
+		typeName = constructorType.Type
+	}
+
+	concreteType, err := c.resolveType(typeName)
+	if err != nil {
+		v.report(err, frame.ErrUnknownType, typeName, name, confPath)
+		return validated
+	}
+	validated.concreteType = concreteType
+	if constructorType.Kind == KindResource {
+		// 资源在被注入时才创建，这里只检查类型
+		return validated
+	}
+	entry, err := c.kindOf(typeName, constructorType.Kind)
+	if err != nil {
+		v.report(err, frame.ErrUnknownType, typeName, name, confPath)
+		return validated
+	}
+	kind := entry.kind
+	if scope, err := frame.ParseScope(constructorType.Scope); err != nil {
+		v.report(err, frame.ErrLoadConfig, typeName, name, confPath)
+	} else if scope != frame.ScopeSingleton && kind.Name != TypeNameHandler {
+		v.report(fmt.Errorf("field scope: %s is only supported by kind %s, %s is kind %s", scope, TypeNameHandler, typeName, kind.Name), frame.ErrLoadConfig, typeName, name, confPath)
+	}
+	if concreteType != nil && kind.Interface != nil && !concreteType.Implements(kind.Interface) {
+		v.report(fmt.Errorf("%s does not implement %s required by kind %s", concreteType, kind.Interface, kind.Name), frame.ErrNotConcrete, typeName, name, confPath)
+	}
+	if err := kind.Parse(data); err != nil {
+		v.report(err, frame.ErrLoadConfig, typeName, name, confPath)
+		// 配置校验失败但仍能读出子组件时，继续检查子组件以汇总更多问题
+		if _, errChildren := kind.Children(data); len(kind.ChildFields) == 0 || errChildren != nil {
+			return validated
+		}
+	}
+
+	if len(kind.ChildFields) == 0 {
+		v.validateLeaf(validated, kind, typeName, name, confPath, data)
+		return validated
+	}
+
+	v.stack = append(v.stack, name)
+	children := make(map[string]*validatedConcrete)
+	for _, field := range kind.ChildFields {
+		childNames, err := kind.FieldChildren(data, field)
+		if err != nil {
+			v.report(err, frame.ErrLoadConfig, typeName, name, confPath)
+			continue
+		}
+		for _, childName := range childNames {
+			child := v.validateChild(childName)
+			children[childName] = child
+			childInterface, ok := kind.ChildInterfaces[field]
+			if !ok || child == nil || child.concreteType == nil {
+				continue
+			}
+			if !child.concreteType.Implements(childInterface) {
+				v.report(fmt.Errorf("field %s references %s of type %s which does not implement %s", field, childName, child.concreteType, childInterface), frame.ErrNotConcrete, typeName, name, confPath)
+			}
+		}
+	}
+	v.stack = v.stack[:len(v.stack)-1]
+
+	if kind.Name == TypeNameLayer {
+		v.validateLayer(children, typeName, name, confPath, data)
+	}
+	return validated
+}
+
+// validateChild 检查子组件，引用的名称既没有配置文件、也不是已注册的类型、也没有被Override时报告ErrNotFound
+func (v *validation) validateChild(name string) *validatedConcrete {
+	c := v.constructor
+	if _, ok := v.visited[name]; ok {
+		return v.validate(name)
+	}
+	_, errConf := c.concreteConfManager.GetConfPath(name)
+	_, errType := c.resolveType(name)
+	c.mutex.Lock()
+	_, overridden := c.overrides[name]
+	c.mutex.Unlock()
+	if errConf != nil && errType != nil && !overridden {
+		parent := v.stack[len(v.stack)-1]
+		parentConfPath, _ := c.concreteConfManager.GetConfPath(parent)
+		v.visited[name] = nil
+		v.report(fmt.Errorf("referenced by %s but has no configuration file and is not a registered type", parent), frame.ErrNotFound, "", name, parentConfPath)
+		return nil
+	}
+	return v.validate(name)
+}
+
+// validateLeaf 临时创建叶子组件以加载它的配置，不会登记名称，也不会被Override或Close看到
+func (v *validation) validateLeaf(validated *validatedConcrete, kind frame.Kind, typeName string, name string, confPath string, data []byte) {
+	c := v.constructor
+	concrete, err := c.factoryInterface.New(typeName, data, c)
+	if err != nil {
+		v.report(err, frame.ErrLoadConfig, typeName, name, confPath)
+		return
+	}
+	validated.concrete = concrete
+	validated.concreteType = reflect.TypeOf(concrete)
+	if !kind.Implements(concrete) {
+		v.report(fmt.Errorf("%T does not implement %s required by kind %s", concrete, kind.Interface, kind.Name), frame.ErrNotConcrete, typeName, name, confPath)
+	}
+	if concreteInterface, ok := concrete.(frame.ConcreteInterface); ok && confPath != "" && concreteInterface.Name() != name {
+		v.report(fmt.Errorf("concrete Name (%s) mismatch with configuration file Name (%s)", concreteInterface.Name(), name), frame.ErrLoadConfig, typeName, name, confPath)
+	}
+}
+
+// validateLayer 检查divider可能选择的每个输出都在layer的handlers或params中，其余配置已由kind.Parse校验
+func (v *validation) validateLayer(children map[string]*validatedConcrete, typeName string, name string, confPath string, data []byte) {
+	conf := struct {
+		Divider  string         `yaml:"divider"`
+		Handlers []string       `yaml:"handlers"`
+		Params   map[string]any `yaml:"params"`
+	}{}
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		v.report(err, frame.ErrLoadConfig, typeName, name, confPath)
+		return
+	}
+	variants := make(map[string]bool)
+	for _, handlerName := range conf.Handlers {
+		variants[handlerName] = true
+	}
+	for variantName := range conf.Params {
+		variants[variantName] = true
+	}
+
+	divider := children[conf.Divider]
+	if divider == nil {
+		return
+	}
+	dividerOutputsInterface, ok := divider.concrete.(frame.DividerOutputsInterface)
+	if !ok {
+		return
+	}
+	for _, output := range dividerOutputsInterface.Outputs() {
+		if !variants[output] {
+			v.report(fmt.Errorf("layer %s divider %s may select %s which is not in handlers", name, conf.Divider, output), frame.ErrLoadConfig, typeName, name, confPath)
+		}
+	}
+}
+
+// release 关闭校验过程中临时创建的叶子组件
+func (v *validation) release() {
+	for _, validated := range v.visited {
+		if validated == nil {
+			continue
+		}
+		if closer, ok := validated.concrete.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+// releaseResources 关闭并删除Validate期间为叶子组件创建的资源，Validate之前已经存在的资源保持不变
+func (c *Constructor) releaseResources(keep int) {
+	c.mutex.Lock()
+	created := append([]string(nil), c.resourceNames[keep:]...)
+	c.resourceNames = c.resourceNames[:keep]
+	resources := make([]any, 0, len(created))
+	for _, name := range created {
+		resources = append(resources, c.resources[name])
+		delete(c.resources, name)
+	}
+	c.mutex.Unlock()
+
+	for i := len(created) - 1; i >= 0; i-- {
+		c.factoryInterface.Remove(created[i])
+		if closer, ok := resources[i].(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+// resolveType 返回类型名称对应的组件类型，Factory不支持frame.TypeResolverInterface或类型通过构造函数注册时返回nil
+func (c *Constructor) resolveType(typeName string) (reflect.Type, error) {
+	typeResolverInterface, ok := c.factoryInterface.(frame.TypeResolverInterface)
+	if !ok {
+		return nil, nil
+	}
+	return typeResolverInterface.ResolveType(typeName)
+}
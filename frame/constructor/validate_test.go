@@ -0,0 +1,106 @@
+package constructor_test
+
+import (
+	"errors"
+	"ghgroups/frame"
+	handlergroup "ghgroups/frame/handler_group"
+	"ghgroups/frame/layer"
+	layercenter "ghgroups/frame/layer_center"
+	"ghgroups/frame/utils"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testOutputsDivider struct {
+	testFixedDivider
+}
+
+func (t *testOutputsDivider) Name() string {
+	return "outputs_divider"
+}
+
+func (t *testOutputsDivider) Outputs() []string {
+	return []string{"handler_ok", "handler_z"}
+}
+
+func TestValidate(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	assert.Nil(t, errGetWd)
+	confPath := path.Join(runPath, "test_data", "validation")
+
+	constructor := utils.BuildConstructor(confPath)
+	constructor.Register(reflect.TypeOf(handlergroup.HandlerGroup{}))
+	constructor.Register(reflect.TypeOf(layer.Layer{}))
+	constructor.Register(reflect.TypeOf(layercenter.LayerCenter{}))
+	constructor.Register(reflect.TypeOf(testNamedHandler{}))
+	constructor.Register(reflect.TypeOf(testFixedDivider{}))
+	constructor.Register(reflect.TypeOf(testOutputsDivider{}))
+
+	t.Run("Input=valid", func(t *testing.T) {
+		assert.Nil(t, constructor.Validate("handler_ok"))
+	})
+
+	t.Run("Input=problems", func(t *testing.T) {
+		err := constructor.Validate("group_root")
+		var validationError *frame.ValidationError
+		assert.True(t, errors.As(err, &validationError))
+
+		problems := make(map[string]*frame.BuildError)
+		for _, problem := range validationError.Problems {
+			assert.NotEmpty(t, problem.Path, problem.Error())
+			problems[problem.Name+" "+problem.Kind.Error()] = problem
+		}
+		assert.Equal(t, 7, len(validationError.Problems), err.Error())
+
+		missing := problems["missing_handler "+frame.ErrNotFound.Error()]
+		assert.NotNil(t, missing)
+		assert.Equal(t, path.Join(confPath, "group_root.yaml"), missing.Path)
+
+		unknown := problems["unknown_handler "+frame.ErrUnknownType.Error()]
+		assert.NotNil(t, unknown)
+		assert.Equal(t, path.Join(confPath, "unknown_handler.yaml"), unknown.Path)
+
+		assert.NotNil(t, problems["bad_name_handler "+frame.ErrLoadConfig.Error()])
+		assert.NotNil(t, problems["layer_bad "+frame.ErrNotConcrete.Error()])
+		assert.NotNil(t, problems["group_root "+frame.ErrCycle.Error()])
+
+		assert.ErrorContains(t, err, "validation found 7 problem(s)")
+		assert.ErrorContains(t, err, "field handlers references fixed_divider")
+		assert.ErrorContains(t, err, "divider outputs_divider may select handler_z which is not in handlers")
+		assert.ErrorContains(t, err, "default not_a_variant is not in handlers")
+		assert.True(t, errors.Is(err, frame.ErrCycle))
+	})
+
+	t.Run("Input=composite_conf", func(t *testing.T) {
+		err := constructor.Validate("center_overlap")
+		var validationError *frame.ValidationError
+		assert.True(t, errors.As(err, &validationError))
+		assert.Equal(t, 2, len(validationError.Problems), err.Error())
+
+		problems := make(map[string]*frame.BuildError)
+		for _, problem := range validationError.Problems {
+			assert.True(t, errors.Is(problem, frame.ErrLoadConfig), problem.Error())
+			problems[problem.Name] = problem
+		}
+		assert.NotNil(t, problems["center_overlap"])
+		assert.Equal(t, path.Join(confPath, "center_overlap.yaml"), problems["center_overlap"].Path)
+		assert.NotNil(t, problems["layer_unknown_policy"])
+
+		assert.ErrorContains(t, err, "domain b [500, 1000) overlaps with domain a [0, 600)")
+		assert.ErrorContains(t, err, "on_unknown bogus is invalid")
+	})
+
+	t.Run("Input=no_instances", func(t *testing.T) {
+		_, err := constructor.GetConcrete("group_root")
+		assert.True(t, errors.Is(err, frame.ErrNotFound))
+		_, err = constructor.Get("handler_ok")
+		assert.True(t, errors.Is(err, frame.ErrNotFound))
+
+		// 校验之后仍然可以正常构建
+		assert.Nil(t, constructor.CreateConcrete("handler_ok"))
+	})
+}
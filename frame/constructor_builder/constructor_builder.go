@@ -1,6 +1,9 @@
 package constructorbuilder
 
 import (
+	"ghgroups"
+	banditdivider "ghgroups/frame/bandit_divider"
+	bucketdivider "ghgroups/frame/bucket_divider"
 	"ghgroups/frame/constructor"
 	aynchandlergroupconstructor "ghgroups/frame/constructor/async_handler_group_constructor"
 	dividerconstructor "ghgroups/frame/constructor/divider_constructor"
@@ -11,22 +14,58 @@ import (
 	"ghgroups/frame/factory"
 	"ghgroups/frame/layer"
 	layercenter "ghgroups/frame/layer_center"
+	ruledivider "ghgroups/frame/rule_divider"
+	weighteddivider "ghgroups/frame/weighted_divider"
 
 	asynchandlergroup "ghgroups/frame/async_handler_group"
 	handlergroup "ghgroups/frame/handler_group"
 	"reflect"
 )
 
-func BuildConstructor(factory *factory.Factory, concretePath string) *constructor.Constructor {
-	factory.Register(reflect.TypeOf(asynchandlergroup.AsyncHandlerGroup{}))
-	factory.Register(reflect.TypeOf(handlergroup.HandlerGroup{}))
-	factory.Register(reflect.TypeOf(layer.Layer{}))
-	factory.Register(reflect.TypeOf(layercenter.LayerCenter{}))
-	factory.Register(reflect.TypeOf(layerconstructor.LayerConstructor{}))
-	factory.Register(reflect.TypeOf(dividerconstructor.DividerConstructor{}))
-	factory.Register(reflect.TypeOf(handlerconstructor.HandlerConstructor{}))
-	factory.Register(reflect.TypeOf(layercenterconstructor.LayerCenterConstructor{}))
-	factory.Register(reflect.TypeOf(handlergroupconstructor.HandlerGroupConstructor{}))
-	factory.Register(reflect.TypeOf(aynchandlergroupconstructor.AsyncHandlerGroupConstructor{}))
+// BuildConstructor 注册所有内置组件和默认注册表中的组件并创建Constructor，失败时返回错误
+func BuildConstructor(factory *factory.Factory, concretePath string) (*constructor.Constructor, error) {
+	concreteTypes := []reflect.Type{
+		reflect.TypeOf(asynchandlergroup.AsyncHandlerGroup{}),
+		reflect.TypeOf(handlergroup.HandlerGroup{}),
+		reflect.TypeOf(layer.Layer{}),
+		reflect.TypeOf(layercenter.LayerCenter{}),
+		reflect.TypeOf(weighteddivider.WeightedDivider{}),
+		reflect.TypeOf(ruledivider.RuleDivider{}),
+		reflect.TypeOf(bucketdivider.BucketDivider{}),
+		reflect.TypeOf(banditdivider.BanditDivider{}),
+		reflect.TypeOf(layerconstructor.LayerConstructor{}),
+		reflect.TypeOf(dividerconstructor.DividerConstructor{}),
+		reflect.TypeOf(handlerconstructor.HandlerConstructor{}),
+		reflect.TypeOf(layercenterconstructor.LayerCenterConstructor{}),
+		reflect.TypeOf(handlergroupconstructor.HandlerGroupConstructor{}),
+		reflect.TypeOf(aynchandlergroupconstructor.AsyncHandlerGroupConstructor{}),
+	}
+	for _, concreteType := range concreteTypes {
+		// 调用方已经注册过同一个内置类型时跳过，只有同名的其他类型或构造函数才是冲突
+		if registeredAs(factory, concreteType) {
+			continue
+		}
+		if err := factory.Register(concreteType); err != nil {
+			return nil, err
+		}
+	}
+	if err := ghgroups.Default().Apply(factory); err != nil {
+		return nil, err
+	}
 	return constructor.NewConstructor(factory, concretePath)
 }
+
+// registeredAs 判断f中是否已经注册了完全相同的类型
+func registeredAs(f *factory.Factory, concreteType reflect.Type) bool {
+	registered, err := f.ResolveType(factory.TypeName(concreteType))
+	return err == nil && registered == reflect.PointerTo(concreteType)
+}
+
+// MustBuildConstructor 与BuildConstructor相同，失败时panic，适合简单的main函数
+func MustBuildConstructor(factory *factory.Factory, concretePath string) *constructor.Constructor {
+	constructor, err := BuildConstructor(factory, concretePath)
+	if err != nil {
+		panic(err)
+	}
+	return constructor
+}
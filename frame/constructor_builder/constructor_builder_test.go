@@ -0,0 +1,31 @@
+package constructorbuilder
+
+import (
+	"errors"
+	"ghgroups/frame"
+	"ghgroups/frame/factory"
+	handlergroup "ghgroups/frame/handler_group"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildConstructor(t *testing.T) {
+	t.Run("Input=registered_builtin", func(t *testing.T) {
+		f := factory.NewFactory()
+		assert.Nil(t, f.Register(reflect.TypeOf(handlergroup.HandlerGroup{})))
+		_, err := BuildConstructor(f, t.TempDir())
+		assert.Nil(t, err)
+	})
+
+	t.Run("Input=conflict", func(t *testing.T) {
+		f := factory.NewFactory()
+		name := factory.TypeName(reflect.TypeOf(handlergroup.HandlerGroup{}))
+		assert.Nil(t, f.RegisterFunc(name, func([]byte, frame.ConstructorInterface) (frame.ConcreteInterface, error) {
+			return handlergroup.NewHandlerGroup(nil), nil
+		}))
+		_, err := BuildConstructor(f, t.TempDir())
+		assert.True(t, errors.Is(err, frame.ErrDuplicateType), err)
+	})
+}
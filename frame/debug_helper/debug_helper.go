@@ -13,8 +13,26 @@ func DealDuration(startTime time.Time, concreteName string, ctx *ghgroupscontext
 }
 
 func HandleWithShowDuration(handlerBaseInterface frame.HandlerBaseInterface, name string, ctx *ghgroupscontext.GhGroupsContext) bool {
+	ctx = ctx.EnterTrace(name)
 	if ctx.ShowDuration {
 		defer DealDuration(time.Now(), name, ctx)
 	}
-	return handlerBaseInterface.Handle(ctx)
+	status := handlerBaseInterface.Handle(ctx)
+	ctx.Trace("status", status)
+	return status
+}
+
+// DebugOverridesEnabled 判断constructor是否允许读取请求中的调试覆盖
+func DebugOverridesEnabled(constructorInterface any) bool {
+	switchInterface, ok := constructorInterface.(frame.DebugOverridesSwitchInterface)
+	return ok && switchInterface.DebugOverridesEnabled()
+}
+
+// SkipDisabled 供组合组件在执行子组件前调用，子组件被调试覆盖关闭时返回true并记录到trace
+func SkipDisabled(constructorInterface any, name string, ctx *ghgroupscontext.GhGroupsContext) bool {
+	if !DebugOverridesEnabled(constructorInterface) || !ctx.ComponentDisabled(name) {
+		return false
+	}
+	ctx.EnterTrace(name).Trace("disabled", true)
+	return true
 }
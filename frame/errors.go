@@ -0,0 +1,104 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 构建过程中的错误种类，使用errors.Is判断，例如 errors.Is(err, frame.ErrDuplicateName)
+var (
+	ErrUnknownType     = errors.New("unknown type")
+	ErrAmbiguousType   = errors.New("ambiguous type alias")
+	ErrDuplicateType   = errors.New("type already registered")
+	ErrNotConcrete     = errors.New("type does not implement ConcreteInterface")
+	ErrLoadConfig      = errors.New("load config failed")
+	ErrLoadEnvironment = errors.New("load environment conf failed")
+	ErrEmptyName       = errors.New("empty name")
+	ErrDuplicateName   = errors.New("name already used")
+	ErrNotFound        = errors.New("not found")
+	ErrInjectResource  = errors.New("inject resource failed")
+	ErrMissingResource = errors.New("missing resource")
+	ErrRebuildRequired = errors.New("rebuild required")
+	ErrCycle           = errors.New("reference cycle")
+	ErrAmbiguousKind   = errors.New("ambiguous kind")
+)
+
+// BuildError 描述构建某个组件时的失败，使用errors.As取出类型名称、组件名称和配置文件路径
+type BuildError struct {
+	Kind error
+	Type string
+	Name string
+	Path string
+	Err  error
+}
+
+func (e *BuildError) Error() string {
+	fields := make([]string, 0, 3)
+	if e.Type != "" {
+		fields = append(fields, "type="+e.Type)
+	}
+	if e.Name != "" {
+		fields = append(fields, "name="+e.Name)
+	}
+	if e.Path != "" {
+		fields = append(fields, "path="+e.Path)
+	}
+	message := e.Kind.Error()
+	if len(fields) > 0 {
+		message = fmt.Sprintf("%s (%s)", message, strings.Join(fields, ", "))
+	}
+	if e.Err != nil {
+		message = fmt.Sprintf("%s: %v", message, e.Err)
+	}
+	return message
+}
+
+func (e *BuildError) Is(target error) bool {
+	return e.Kind == target
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+// WithBuildInfo 为BuildError补充调用方才知道的组件名称和配置文件路径，已有的信息不会被覆盖
+// err不是BuildError时原样返回
+func WithBuildInfo(err error, name string, path string) error {
+	buildError, ok := err.(*BuildError)
+	if !ok {
+		return err
+	}
+	annotated := *buildError
+	if annotated.Name == "" {
+		annotated.Name = name
+	}
+	if annotated.Path == "" {
+		annotated.Path = path
+	}
+	return &annotated
+}
+
+// ValidationError 汇总Constructor.Validate发现的所有问题，每个问题都带有组件名称和配置文件路径
+type ValidationError struct {
+	Problems []*BuildError
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems)+1)
+	lines = append(lines, fmt.Sprintf("validation found %d problem(s)", len(e.Problems)))
+	for _, problem := range e.Problems {
+		lines = append(lines, "  "+problem.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Is 任意一个问题属于target时返回true，例如 errors.Is(err, frame.ErrUnknownType)
+func (e *ValidationError) Is(target error) bool {
+	for _, problem := range e.Problems {
+		if errors.Is(problem, target) {
+			return true
+		}
+	}
+	return false
+}
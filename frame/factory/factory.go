@@ -4,11 +4,15 @@ import (
 	"fmt"
 	"ghgroups/frame"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 type Factory struct {
 	frame.FactoryInterface
 	concretesType map[string]reflect.Type
+	concretesFunc map[string]frame.ConcreteFunc
+	aliases       map[string][]string
 	concretes     map[string]frame.ConcreteInterface
 }
 
@@ -16,22 +20,90 @@ func NewFactory() *Factory {
 
 	return &Factory{
 		concretesType: make(map[string]reflect.Type),
+		concretesFunc: make(map[string]frame.ConcreteFunc),
+		aliases:       make(map[string][]string),
 		concretes:     make(map[string]frame.ConcreteInterface),
 	}
 }
 
+// Create 按类型名称创建组件并加载配置，失败时返回*frame.BuildError
+// 创建出的组件名称全局唯一，重复使用同一个名称会返回frame.ErrDuplicateName
 func (f *Factory) Create(concreteTypeName string, configure []byte, constructorInterface any) (concrete any, err error) {
+	concrete, err = f.New(concreteTypeName, configure, constructorInterface)
+	if err != nil {
+		return nil, err
+	}
+
+	concreteInterface := concrete.(frame.ConcreteInterface)
+	concreteName := concreteInterface.Name()
+	if _, ok := f.concretes[concreteName]; ok {
+		return nil, &frame.BuildError{Kind: frame.ErrDuplicateName, Type: concreteTypeName, Name: concreteName}
+	}
+	f.concretes[concreteName] = concreteInterface
+	return concreteInterface, nil
+}
+
+// New 与Create相同但不登记组件名称，用于per_reference、per_request等同名组件有多个实例的场景
+// 通过RegisterFunc注册的类型由构造函数创建，否则通过反射创建零值再经由接口加载配置
+// concreteTypeName可以是完整类型名称（包路径.类型名），也可以是别名
+func (f *Factory) New(concreteTypeName string, configure []byte, constructorInterface any) (any, error) {
+	concreteTypeName, err := f.resolve(concreteTypeName)
+	if err != nil {
+		return nil, err
+	}
+
+	var concreteInterface frame.ConcreteInterface
+	if concreteFunc, ok := f.concretesFunc[concreteTypeName]; ok {
+		concreteInterface, err = f.createByFunc(concreteTypeName, concreteFunc, configure, constructorInterface)
+	} else {
+		concreteInterface, err = f.createByType(concreteTypeName, configure, constructorInterface)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if concreteInterface.Name() == "" {
+		return nil, &frame.BuildError{Kind: frame.ErrEmptyName, Type: concreteTypeName}
+	}
+	return concreteInterface, nil
+}
+
+func (f *Factory) createByFunc(concreteTypeName string, concreteFunc frame.ConcreteFunc, configure []byte, constructorInterface any) (frame.ConcreteInterface, error) {
+	constructor, _ := constructorInterface.(frame.ConstructorInterface)
+	concreteInterface, err := concreteFunc(configure, constructor)
+	if err != nil {
+		return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Type: concreteTypeName, Err: err}
+	}
+	// 返回值为带类型的nil指针时接口本身不为nil，之后调用Name会panic
+	if concreteInterface == nil || isNilPointer(concreteInterface) {
+		return nil, &frame.BuildError{Kind: frame.ErrNotConcrete, Type: concreteTypeName}
+	}
+
+	// 构造函数返回后才能注入资源，构造函数中需要使用资源时应通过ConstructorInterface.Resource获取
+	injectorInterface, ok := constructorInterface.(frame.InjectorInterface)
+	if ok && injectorInterface != nil {
+		if err := injectorInterface.Inject(concreteInterface); err != nil {
+			return nil, &frame.BuildError{Kind: frame.ErrInjectResource, Type: concreteTypeName, Err: err}
+		}
+	}
+	return concreteInterface, nil
+}
+
+func isNilPointer(concrete any) bool {
+	value := reflect.ValueOf(concrete)
+	return value.Kind() == reflect.Pointer && value.IsNil()
+}
+
+func (f *Factory) createByType(concreteTypeName string, configure []byte, constructorInterface any) (frame.ConcreteInterface, error) {
 	concreteType, ok := f.concretesType[concreteTypeName]
 	if !ok {
-		err = fmt.Errorf("concrete name not found for %s", concreteTypeName)
-		panic(err.Error())
+		return nil, &frame.BuildError{Kind: frame.ErrUnknownType, Type: concreteTypeName}
 	}
 
-	concrete = reflect.New(concreteType).Interface()
+	concrete := reflect.New(concreteType).Interface()
 	concreteInterface, ok := concrete.(frame.ConcreteInterface)
 	if !ok || concreteInterface == nil {
-		err = fmt.Errorf("concrete %s conver to ConcreteInterface error", concreteTypeName)
-		panic(err.Error())
+		return nil, &frame.BuildError{Kind: frame.ErrNotConcrete, Type: concreteTypeName}
 	}
 
 	constructorSetterInterface, ok := concrete.(frame.ConstructorSetterInterface)
@@ -39,12 +111,19 @@ func (f *Factory) Create(concreteTypeName string, configure []byte, constructorI
 		constructorSetterInterface.SetConstructorInterface(constructorInterface)
 	}
 
+	// 在加载配置之前注入资源，组件在LoadConfigFromMemory中就可以使用
+	injectorInterface, ok := constructorInterface.(frame.InjectorInterface)
+	if ok && injectorInterface != nil {
+		if err := injectorInterface.Inject(concrete); err != nil {
+			return nil, &frame.BuildError{Kind: frame.ErrInjectResource, Type: concreteTypeName, Err: err}
+		}
+	}
+
 	loadConfigFromMemoryInterface, ok := concrete.(frame.LoadConfigFromMemoryInterface)
 	if ok && loadConfigFromMemoryInterface != nil {
 		err := loadConfigFromMemoryInterface.LoadConfigFromMemory(configure)
 		if err != nil {
-			err = fmt.Errorf("concrete LoadConfigFromMemory error for %s .error: %v", concreteTypeName, err)
-			panic(err.Error())
+			return nil, &frame.BuildError{Kind: frame.ErrLoadConfig, Type: concreteTypeName, Err: err}
 		}
 	}
 
@@ -52,40 +131,153 @@ func (f *Factory) Create(concreteTypeName string, configure []byte, constructorI
 	if ok && loadEnvironmentConfInterface != nil {
 		err := loadEnvironmentConfInterface.LoadEnvironmentConf()
 		if err != nil {
-			err = fmt.Errorf("concrete LoadEnvironmentConf error for %s .error: %v", concreteTypeName, err)
-			panic(err.Error())
+			return nil, &frame.BuildError{Kind: frame.ErrLoadEnvironment, Type: concreteTypeName, Err: err}
 		}
 	}
+	return concreteInterface, nil
+}
+
+// MustCreate 与Create相同，失败时panic
+func (f *Factory) MustCreate(concreteTypeName string, configure []byte, constructorInterface any) any {
+	concrete, err := f.Create(concreteTypeName, configure, constructorInterface)
+	if err != nil {
+		panic(err)
+	}
+	return concrete
+}
 
-	concreteName := concreteInterface.Name()
-	if concreteName == "" {
-		err = fmt.Errorf("concrete's Name is empty for %s", concreteTypeName)
-		panic(err.Error())
+// Register 以完整类型名称（包路径.类型名）注册类型，类型名和aliases作为配置文件type字段可用的别名
+func (f *Factory) Register(concreteType reflect.Type, aliases ...string) error {
+	concreteTypeName := TypeName(concreteType)
+	if f.registered(concreteTypeName) {
+		return &frame.BuildError{Kind: frame.ErrDuplicateType, Type: concreteTypeName}
 	}
-	if _, ok := f.concretes[concreteName]; !ok {
-		f.concretes[concreteName] = concreteInterface
-	} else {
-		err = fmt.Errorf("concrete's Name  %s has already been used, type is %s", concreteName, concreteTypeName)
-		panic(err.Error())
+	f.concretesType[concreteTypeName] = concreteType
+	f.addAliases(concreteTypeName, append([]string{concreteType.Name()}, aliases...))
+	return nil
+}
+
+// MustRegister 与Register相同，失败时panic
+func (f *Factory) MustRegister(concreteType reflect.Type, aliases ...string) {
+	if err := f.Register(concreteType, aliases...); err != nil {
+		panic(err)
 	}
-	return
 }
 
-func (f *Factory) Register(concreteType reflect.Type) (err error) {
-	concreteName := concreteType.Name()
-	if _, ok := f.concretesType[concreteName]; !ok {
-		f.concretesType[concreteName] = concreteType
-	} else {
-		err = fmt.Errorf(concreteName + " is already registered.Please modify name.")
-		panic(err.Error())
+// RegisterType 注册组件类型T，T必须实现frame.ConcreteInterface，通常是结构体指针
+func RegisterType[T frame.ConcreteInterface](f *Factory, aliases ...string) error {
+	return f.Register(TypeOf[T](), aliases...)
+}
+
+// TypeOf 返回组件类型T对应的可注册类型，T是指针时返回其指向的类型
+func TypeOf[T frame.ConcreteInterface]() reflect.Type {
+	concreteType := reflect.TypeOf((*T)(nil)).Elem()
+	if concreteType.Kind() == reflect.Pointer {
+		concreteType = concreteType.Elem()
+	}
+	return concreteType
+}
+
+// RegisterFunc 以构造函数注册类型，与Register共用同一个类型名称空间
+// 构造函数返回的组件同样会按ghgroups:"resource=..."注入资源，但注入发生在构造函数返回之后
+func (f *Factory) RegisterFunc(concreteTypeName string, concreteFunc frame.ConcreteFunc, aliases ...string) error {
+	if concreteTypeName == "" || concreteFunc == nil {
+		return &frame.BuildError{Kind: frame.ErrNotConcrete, Type: concreteTypeName}
 	}
+	if f.registered(concreteTypeName) {
+		return &frame.BuildError{Kind: frame.ErrDuplicateType, Type: concreteTypeName}
+	}
+	f.concretesFunc[concreteTypeName] = concreteFunc
+	f.addAliases(concreteTypeName, aliases)
 	return nil
 }
 
+// MustRegisterFunc 与RegisterFunc相同，失败时panic
+func (f *Factory) MustRegisterFunc(concreteTypeName string, concreteFunc frame.ConcreteFunc, aliases ...string) {
+	if err := f.RegisterFunc(concreteTypeName, concreteFunc, aliases...); err != nil {
+		panic(err)
+	}
+}
+
+// TypeName 返回类型在Factory中的完整名称：包路径.类型名
+func TypeName(concreteType reflect.Type) string {
+	if concreteType.PkgPath() == "" {
+		return concreteType.Name()
+	}
+	return concreteType.PkgPath() + "." + concreteType.Name()
+}
+
+// Has 判断完整类型名称是否已经注册
+func (f *Factory) Has(concreteTypeName string) bool {
+	return f.registered(concreteTypeName)
+}
+
+// ResolveType 返回配置文件中的type对应的组件类型，通过RegisterFunc注册的类型返回nil
+func (f *Factory) ResolveType(concreteTypeName string) (reflect.Type, error) {
+	concreteTypeName, err := f.resolve(concreteTypeName)
+	if err != nil {
+		return nil, err
+	}
+	if concreteType, ok := f.concretesType[concreteTypeName]; ok {
+		return reflect.PointerTo(concreteType), nil
+	}
+	return nil, nil
+}
+
+func (f *Factory) registered(concreteTypeName string) bool {
+	if _, ok := f.concretesType[concreteTypeName]; ok {
+		return true
+	}
+	_, ok := f.concretesFunc[concreteTypeName]
+	return ok
+}
+
+func (f *Factory) addAliases(concreteTypeName string, aliases []string) {
+	for _, alias := range aliases {
+		if alias == "" || alias == concreteTypeName {
+			continue
+		}
+		if contains(f.aliases[alias], concreteTypeName) {
+			continue
+		}
+		f.aliases[alias] = append(f.aliases[alias], concreteTypeName)
+	}
+}
+
+// resolve 把配置文件中的type转换为完整类型名称，别名对应多个类型时返回ErrAmbiguousType
+func (f *Factory) resolve(concreteTypeName string) (string, error) {
+	if f.registered(concreteTypeName) {
+		return concreteTypeName, nil
+	}
+	candidates := f.aliases[concreteTypeName]
+	switch len(candidates) {
+	case 0:
+		return "", &frame.BuildError{Kind: frame.ErrUnknownType, Type: concreteTypeName}
+	case 1:
+		return candidates[0], nil
+	}
+	sorted := append([]string{}, candidates...)
+	sort.Strings(sorted)
+	return "", &frame.BuildError{Kind: frame.ErrAmbiguousType, Type: concreteTypeName, Err: fmt.Errorf("candidates are %s", strings.Join(sorted, ", "))}
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (f *Factory) Get(concreteName string) (frame.ConcreteInterface, error) {
 	if concrete, ok := f.concretes[concreteName]; ok {
 		return concrete, nil
-	} else {
-		return nil, nil
 	}
+	return nil, &frame.BuildError{Kind: frame.ErrNotFound, Name: concreteName}
+}
+
+// Remove 释放组件名称，之后可以用同一个名称重新创建组件
+func (f *Factory) Remove(concreteName string) {
+	delete(f.concretes, concreteName)
 }
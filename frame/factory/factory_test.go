@@ -1,6 +1,7 @@
 package factory
 
 import (
+	"errors"
 	"fmt"
 	"ghgroups/frame"
 	"reflect"
@@ -24,15 +25,26 @@ func TestRegister(t *testing.T) {
 		factory.Register(reflect.TypeOf(TestSameName{}))
 	})
 
-	t.Run("Input=RegisterPanic", func(t *testing.T) {
+	t.Run("Input=RegisterSameName", func(t *testing.T) {
+		factory := NewFactory()
+		assert.Nil(t, factory.Register(reflect.TypeOf(TestSameName{})))
+		err := factory.Register(reflect.TypeOf(TestSameName{}))
+		assert.True(t, errors.Is(err, frame.ErrDuplicateType))
+		assert.EqualError(t, err, "type already registered (type=ghgroups/frame/factory.TestSameName)")
+	})
+
+	t.Run("Input=MustRegisterPanic", func(t *testing.T) {
 		defer func() {
 			r := recover()
-			assert.Equal(t, r, "TestSameName is already registered.Please modify name.")
+			err, ok := r.(error)
+			assert.True(t, ok)
+			assert.True(t, errors.Is(err, frame.ErrDuplicateType))
 		}()
 
 		factory := NewFactory()
-		factory.Register(reflect.TypeOf(TestSameName{}))
-		factory.Register(reflect.TypeOf(TestSameName{}))
+		factory.MustRegister(reflect.TypeOf(TestSameName{}))
+		factory.MustRegister(reflect.TypeOf(TestSameName{}))
+		assert.FailNow(t, "must panic when type is registered twice")
 	})
 }
 
@@ -61,45 +73,60 @@ func TestCreate(t *testing.T) {
 	}
 
 	t.Run("Input=CreateNotExist", func(t *testing.T) {
+		factory := NewFactory()
+		i, err := factory.Create(reflect.TypeOf(TestConcrete{}).Name(), nil, nil)
+		assert.Nil(t, i)
+		assert.True(t, errors.Is(err, frame.ErrUnknownType))
+		var buildError *frame.BuildError
+		assert.True(t, errors.As(err, &buildError))
+		assert.Equal(t, "TestConcrete", buildError.Type)
+	})
+
+	t.Run("Input=MustCreatePanic", func(t *testing.T) {
 		defer func() {
 			r := recover()
-			assert.Equal(t, r, "concrete name not found for TestConcrete")
+			err, ok := r.(error)
+			assert.True(t, ok)
+			assert.True(t, errors.Is(err, frame.ErrUnknownType))
 		}()
 
 		factory := NewFactory()
-		factory.Create(reflect.TypeOf(TestConcrete{}).Name(), nil, nil)
+		factory.MustCreate(reflect.TypeOf(TestConcrete{}).Name(), nil, nil)
+		assert.FailNow(t, "must panic when type is not registered")
 	})
 
 	t.Run("Input=CreateNotImplementsInterface", func(t *testing.T) {
-		defer func() {
-			r := recover()
-			assert.Equal(t, r, "concrete TestConcrete conver to ConcreteInterface error")
-		}()
-
 		factory := NewFactory()
 		factory.Register(reflect.TypeOf(TestConcrete{}))
-		factory.Create(reflect.TypeOf(TestConcrete{}).Name(), nil, nil)
+		_, err := factory.Create(reflect.TypeOf(TestConcrete{}).Name(), nil, nil)
+		assert.True(t, errors.Is(err, frame.ErrNotConcrete))
 	})
 
 	t.Run("Input=CreateInitError", func(t *testing.T) {
-		defer func() {
-			r := recover()
-			assert.Equal(t, r, "concrete LoadConfigFromMemory error for TestConcreteWithInterface .error: ")
-		}()
-
 		factory := NewFactory()
 		factory.Register(reflect.TypeOf(TestConcreteWithInterface{}))
 		testConcreteWithInterface := TestConcreteWithInterface{}
 
 		monkey.PatchInstanceMethod(reflect.TypeOf(&testConcreteWithInterface), "LoadConfigFromMemory", func(*TestConcreteWithInterface, []byte) error {
-			return fmt.Errorf("")
+			return fmt.Errorf("boom")
 		})
 		defer monkey.UnpatchAll()
 
-		factory.Create(reflect.TypeOf(testConcreteWithInterface).Name(), nil, nil)
+		_, err := factory.Create(reflect.TypeOf(testConcreteWithInterface).Name(), nil, nil)
+		assert.True(t, errors.Is(err, frame.ErrLoadConfig))
+		assert.EqualError(t, err, "load config failed (type=ghgroups/frame/factory.TestConcreteWithInterface): boom")
 	})
 
-	t.Run("Input=CreateInitError", func(t *testing.T) {
+	t.Run("Input=CreateSameName", func(t *testing.T) {
+		factory := NewFactory()
+		factory.Register(reflect.TypeOf(TestConcreteWithInterface{}))
+		_, err := factory.Create("TestConcreteWithInterface", nil, nil)
+		assert.Nil(t, err)
+		_, err = factory.Create("TestConcreteWithInterface", nil, nil)
+		assert.True(t, errors.Is(err, frame.ErrDuplicateName))
+	})
+
+	t.Run("Input=CreateSuc", func(t *testing.T) {
 		factory := NewFactory()
 		factory.Register(reflect.TypeOf(TestConcreteWithInterface{}))
 		testConcreteWithInterface := TestConcreteWithInterface{}
@@ -108,3 +135,133 @@ func TestCreate(t *testing.T) {
 		assert.Nil(t, e)
 	})
 }
+
+func TestGet(t *testing.T) {
+	factory := NewFactory()
+	factory.Register(reflect.TypeOf(TestConcreteWithInterface{}))
+	_, err := factory.Get("TestConcreteWithInterface")
+	assert.True(t, errors.Is(err, frame.ErrNotFound))
+
+	_, err = factory.Create("TestConcreteWithInterface", nil, nil)
+	assert.Nil(t, err)
+	concrete, err := factory.Get("TestConcreteWithInterface")
+	assert.Nil(t, err)
+	assert.Equal(t, "TestConcreteWithInterface", concrete.Name())
+}
+
+type testFuncConcrete struct {
+	name    string
+	prefix  string
+	created bool
+}
+
+func (t *testFuncConcrete) Name() string {
+	return t.name
+}
+
+func TestRegisterFunc(t *testing.T) {
+	newTestFuncConcrete := func(prefix string) frame.ConcreteFunc {
+		return func(configure []byte, constructorInterface frame.ConstructorInterface) (frame.ConcreteInterface, error) {
+			name := string(configure)
+			if name == "" {
+				return nil, fmt.Errorf("name is required")
+			}
+			return &testFuncConcrete{name: name, prefix: prefix, created: true}, nil
+		}
+	}
+
+	t.Run("Input=CreateSuc", func(t *testing.T) {
+		factory := NewFactory()
+		assert.Nil(t, factory.RegisterFunc("TestFuncConcrete", newTestFuncConcrete("dep")))
+		i, err := factory.Create("TestFuncConcrete", []byte("func_a"), nil)
+		assert.Nil(t, err)
+		concrete, ok := i.(*testFuncConcrete)
+		assert.True(t, ok)
+		assert.True(t, concrete.created)
+		assert.Equal(t, "dep", concrete.prefix)
+
+		got, err := factory.Get("func_a")
+		assert.Nil(t, err)
+		assert.Equal(t, i, got)
+	})
+
+	t.Run("Input=CreateInvalidConf", func(t *testing.T) {
+		factory := NewFactory()
+		factory.MustRegisterFunc("TestFuncConcrete", newTestFuncConcrete("dep"))
+		_, err := factory.Create("TestFuncConcrete", nil, nil)
+		assert.True(t, errors.Is(err, frame.ErrLoadConfig))
+		assert.EqualError(t, err, "load config failed (type=TestFuncConcrete): name is required")
+	})
+
+	t.Run("Input=CreateNil", func(t *testing.T) {
+		factory := NewFactory()
+		factory.RegisterFunc("TestFuncConcrete", func([]byte, frame.ConstructorInterface) (frame.ConcreteInterface, error) {
+			return nil, nil
+		})
+		_, err := factory.Create("TestFuncConcrete", nil, nil)
+		assert.True(t, errors.Is(err, frame.ErrNotConcrete))
+	})
+
+	t.Run("Input=CreateTypedNil", func(t *testing.T) {
+		factory := NewFactory()
+		factory.RegisterFunc("TestFuncConcrete", func([]byte, frame.ConstructorInterface) (frame.ConcreteInterface, error) {
+			var concrete *testFuncConcrete
+			return concrete, nil
+		})
+		_, err := factory.Create("TestFuncConcrete", nil, nil)
+		assert.True(t, errors.Is(err, frame.ErrNotConcrete))
+	})
+
+	t.Run("Input=RegisterSameName", func(t *testing.T) {
+		factory := NewFactory()
+		factory.Register(reflect.TypeOf(TestConcreteWithInterface{}))
+		err := factory.RegisterFunc("ghgroups/frame/factory.TestConcreteWithInterface", newTestFuncConcrete("dep"))
+		assert.True(t, errors.Is(err, frame.ErrDuplicateType))
+
+		assert.Nil(t, factory.RegisterFunc("TestFuncConcrete", newTestFuncConcrete("dep")))
+		err = factory.RegisterFunc("TestFuncConcrete", newTestFuncConcrete("dep"))
+		assert.True(t, errors.Is(err, frame.ErrDuplicateType))
+	})
+}
+
+func TestAlias(t *testing.T) {
+	type FilterHandler struct {
+		TestConcreteWithInterface
+	}
+
+	t.Run("Input=ShortName", func(t *testing.T) {
+		factory := NewFactory()
+		assert.Nil(t, factory.Register(reflect.TypeOf(TestConcreteWithInterface{}), "test_concrete"))
+		for _, typeName := range []string{"TestConcreteWithInterface", "test_concrete", "ghgroups/frame/factory.TestConcreteWithInterface"} {
+			factory.concretes = make(map[string]frame.ConcreteInterface)
+			_, err := factory.Create(typeName, nil, nil)
+			assert.Nil(t, err, typeName)
+		}
+	})
+
+	t.Run("Input=Ambiguous", func(t *testing.T) {
+		factory := NewFactory()
+		assert.Nil(t, factory.Register(reflect.TypeOf(FilterHandler{})))
+		assert.Nil(t, factory.RegisterFunc("other/team.FilterHandler", func([]byte, frame.ConstructorInterface) (frame.ConcreteInterface, error) {
+			return &testFuncConcrete{name: "filter"}, nil
+		}, "FilterHandler", "other_filter"))
+
+		_, err := factory.Create("FilterHandler", nil, nil)
+		assert.True(t, errors.Is(err, frame.ErrAmbiguousType))
+		assert.EqualError(t, err, "ambiguous type alias (type=FilterHandler): candidates are ghgroups/frame/factory.FilterHandler, other/team.FilterHandler")
+
+		_, err = factory.Create("other_filter", nil, nil)
+		assert.Nil(t, err)
+		_, err = factory.Create("ghgroups/frame/factory.FilterHandler", nil, nil)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Input=RegisterType", func(t *testing.T) {
+		factory := NewFactory()
+		assert.Nil(t, RegisterType[*TestConcreteWithInterface](factory))
+		err := factory.Register(reflect.TypeOf(TestConcreteWithInterface{}))
+		assert.True(t, errors.Is(err, frame.ErrDuplicateType))
+		_, err = factory.Create("TestConcreteWithInterface", nil, nil)
+		assert.Nil(t, err)
+	})
+}
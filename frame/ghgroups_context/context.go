@@ -1,17 +1,85 @@
 package ghgroupscontext
 
+import "sync"
+
 type GhGroupsContext struct {
 	ShowDuration bool
 	context      any
+	shared       *sharedState
+	traceNode    *TraceNode
+}
+
+// sharedState 在同一次请求派生出的所有GhGroupsContext之间共享，异步handler中也可以安全读写
+type sharedState struct {
+	mutex      sync.RWMutex
+	attributes map[string]any
+	errors     []error
+	exposures  []Exposure
+	metrics    []Metric
+	holdout    map[string]string
+	params     map[string]any
+	debug      debugOverrides
 }
 
 func NewGhGroupsContext(context any) *GhGroupsContext {
 	return &GhGroupsContext{
 		ShowDuration: false,
 		context:      context,
+		shared:       newSharedState(),
+	}
+}
+
+func newSharedState() *sharedState {
+	return &sharedState{
+		attributes: make(map[string]any),
 	}
 }
 
 func (s *GhGroupsContext) Context() any {
 	return s.context
 }
+
+// 零值的GhGroupsContext在第一次写入时才分配共享状态，并发场景请使用NewGhGroupsContext构建
+func (s *GhGroupsContext) sharedState() *sharedState {
+	if s.shared == nil {
+		s.shared = newSharedState()
+	}
+	return s.shared
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// 请求属性，供divider等组件按设备、地域、广告位等维度做判断
+func (s *GhGroupsContext) SetAttribute(key string, value any) {
+	shared := s.sharedState()
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	shared.attributes[key] = value
+}
+
+func (s *GhGroupsContext) GetAttribute(key string) (any, bool) {
+	if s.shared == nil {
+		return nil, false
+	}
+	s.shared.mutex.RLock()
+	defer s.shared.mutex.RUnlock()
+	value, ok := s.shared.attributes[key]
+	return value, ok
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// 请求过程中的结构化错误，组件通过Handle返回值之外的方式上报异常原因
+func (s *GhGroupsContext) ReportError(err error) {
+	shared := s.sharedState()
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	shared.errors = append(shared.errors, err)
+}
+
+func (s *GhGroupsContext) Errors() []error {
+	if s.shared == nil {
+		return nil
+	}
+	s.shared.mutex.RLock()
+	defer s.shared.mutex.RUnlock()
+	return append([]error(nil), s.shared.errors...)
+}
@@ -0,0 +1,182 @@
+package ghgroupscontext
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttribute(t *testing.T) {
+	t.Run("Input=ZeroValue", func(t *testing.T) {
+		var ctx GhGroupsContext
+		_, ok := ctx.GetAttribute("device")
+		assert.False(t, ok)
+		ctx.SetAttribute("device", "ios")
+		value, ok := ctx.GetAttribute("device")
+		assert.True(t, ok)
+		assert.Equal(t, "ios", value)
+	})
+
+	t.Run("Input=Concurrent", func(t *testing.T) {
+		ctx := NewGhGroupsContext(nil)
+		wg := sync.WaitGroup{}
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				ctx.SetAttribute(fmt.Sprint(i), i)
+			}(i)
+		}
+		wg.Wait()
+		for i := 0; i < 8; i++ {
+			value, ok := ctx.GetAttribute(fmt.Sprint(i))
+			assert.True(t, ok)
+			assert.Equal(t, i, value)
+		}
+	})
+}
+
+func TestTrace(t *testing.T) {
+	t.Run("Input=Disabled", func(t *testing.T) {
+		ctx := NewGhGroupsContext(nil)
+		assert.Same(t, ctx, ctx.EnterTrace("child"))
+		ctx.Trace("key", "value")
+		assert.Nil(t, ctx.TraceRoot())
+	})
+
+	t.Run("Input=Enabled", func(t *testing.T) {
+		ctx := NewGhGroupsContext(nil)
+		ctx.EnableTrace()
+		ctx.SetAttribute("device", "ios")
+
+		group := ctx.EnterTrace("group")
+		first := group.EnterTrace("first")
+		first.Trace("status", true)
+		second := group.EnterTrace("second")
+		second.Trace("status", false)
+
+		value, ok := second.GetAttribute("device")
+		assert.True(t, ok)
+		assert.Equal(t, "ios", value)
+
+		root := ctx.TraceRoot()
+		assert.Len(t, root.Children, 1)
+		assert.Len(t, root.Find("group").Children, 2)
+		status, ok := root.Find("second").Get("status")
+		assert.True(t, ok)
+		assert.Equal(t, false, status)
+		assert.Equal(t, "group\n\tfirst status=true\n\tsecond status=false\n", root.String())
+	})
+}
+
+func TestReportError(t *testing.T) {
+	ctx := NewGhGroupsContext(nil)
+	assert.Empty(t, ctx.Errors())
+	ctx.ReportError(fmt.Errorf("first"))
+	ctx.EnableTrace()
+	ctx.EnterTrace("child").ReportError(fmt.Errorf("second"))
+	errs := ctx.Errors()
+	assert.Len(t, errs, 2)
+	assert.EqualError(t, errs[1], "second")
+}
+
+func TestExpose(t *testing.T) {
+	var ctx GhGroupsContext
+	assert.Empty(t, ctx.Exposures())
+	ctx.Expose(Exposure{Layer: "layer_a", Handler: "handler_a"})
+	ctx.Expose(Exposure{Layer: "layer_b", Handler: "handler_b", Forced: true, Reason: "override"})
+	assert.Equal(t, []Exposure{
+		{Layer: "layer_a", Handler: "handler_a"},
+		{Layer: "layer_b", Handler: "handler_b", Forced: true, Reason: "override"},
+	}, ctx.Exposures())
+}
+
+func TestDebugOverrides(t *testing.T) {
+	t.Run("Input=header", func(t *testing.T) {
+		ctx := NewGhGroupsContext(nil)
+		assert.Nil(t, ctx.ParseDebugOverrides(" layer_c = ExampleC1Handler, !ExampleDHandler ,"))
+		handler, ok := ctx.DebugSelection("layer_c")
+		assert.True(t, ok)
+		assert.Equal(t, "ExampleC1Handler", handler)
+		assert.True(t, ctx.ComponentDisabled("ExampleDHandler"))
+		assert.False(t, ctx.ComponentDisabled("ExampleC1Handler"))
+	})
+
+	t.Run("Input=invalid", func(t *testing.T) {
+		ctx := NewGhGroupsContext(nil)
+		assert.ErrorContains(t, ctx.ParseDebugOverrides("layer_c"), `debug override "layer_c" is invalid`)
+		assert.ErrorContains(t, ctx.ParseDebugOverrides("!"), `debug override "!" has no component name`)
+	})
+
+	t.Run("Input=zero_value", func(t *testing.T) {
+		var ctx GhGroupsContext
+		_, ok := ctx.DebugSelection("layer_c")
+		assert.False(t, ok)
+		assert.False(t, ctx.ComponentDisabled("ExampleDHandler"))
+	})
+}
+
+func TestReportMetric(t *testing.T) {
+	var ctx GhGroupsContext
+	assert.Empty(t, ctx.Metrics())
+	ctx.ReportMetric("revenue", 1.5)
+	ctx.ReportMetric("won", 1)
+	assert.Equal(t, []Metric{{Name: "revenue", Value: 1.5}, {Name: "won", Value: 1}}, ctx.Metrics())
+}
+
+func TestHoldout(t *testing.T) {
+	var ctx GhGroupsContext
+	assert.False(t, ctx.InHoldout())
+	ctx.SetHoldout(map[string]string{"layer_a": "handler_control"})
+	assert.True(t, ctx.InHoldout())
+	handler, ok := ctx.HoldoutSelection("layer_a")
+	assert.True(t, ok)
+	assert.Equal(t, "handler_control", handler)
+	_, ok = ctx.HoldoutSelection("layer_b")
+	assert.False(t, ok)
+
+	// 第二个LayerCenter的controls与第一个合并，不会覆盖
+	ctx.SetHoldout(map[string]string{"layer_a": "handler_other", "layer_b": "handler_control_b"})
+	handler, _ = ctx.HoldoutSelection("layer_a")
+	assert.Equal(t, "handler_control", handler)
+	handler, ok = ctx.HoldoutSelection("layer_b")
+	assert.True(t, ok)
+	assert.Equal(t, "handler_control_b", handler)
+}
+
+func TestParams(t *testing.T) {
+	var ctx GhGroupsContext
+	_, ok := ctx.ParamInt("top_k")
+	assert.False(t, ok)
+
+	ctx.SetParams(map[string]any{"top_k": 10, "bid_multiplier": 1.5, "ratio": 2.0, "strategy": "greedy", "enabled": true})
+	ctx.SetParams(map[string]any{"top_k": 20})
+
+	topK, ok := ctx.ParamInt("top_k")
+	assert.True(t, ok)
+	assert.Equal(t, 20, topK)
+	ratio, ok := ctx.ParamInt("ratio")
+	assert.True(t, ok)
+	assert.Equal(t, 2, ratio)
+	_, ok = ctx.ParamInt("bid_multiplier")
+	assert.False(t, ok)
+
+	multiplier, ok := ctx.ParamFloat("bid_multiplier")
+	assert.True(t, ok)
+	assert.Equal(t, 1.5, multiplier)
+	topKFloat, ok := ctx.ParamFloat("top_k")
+	assert.True(t, ok)
+	assert.Equal(t, 20.0, topKFloat)
+
+	strategy, ok := ctx.ParamString("strategy")
+	assert.True(t, ok)
+	assert.Equal(t, "greedy", strategy)
+	_, ok = ctx.ParamString("top_k")
+	assert.False(t, ok)
+
+	enabled, ok := ctx.ParamBool("enabled")
+	assert.True(t, ok)
+	assert.True(t, enabled)
+}
@@ -0,0 +1,82 @@
+package ghgroupscontext
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 单次请求的调试覆盖：强制某个Layer选择指定的handler，或者跳过某些组件
+// 只有constructor打开了调试开关，组件才会读取这里的设置，线上默认不生效
+
+type debugOverrides struct {
+	selections map[string]string
+	disabled   map[string]struct{}
+}
+
+// SetDebugSelection 强制名为layer的Layer选择handler，不再调用divider
+func (s *GhGroupsContext) SetDebugSelection(layer string, handler string) {
+	shared := s.sharedState()
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	if shared.debug.selections == nil {
+		shared.debug.selections = make(map[string]string)
+	}
+	shared.debug.selections[layer] = handler
+}
+
+func (s *GhGroupsContext) DebugSelection(layer string) (string, bool) {
+	if s.shared == nil {
+		return "", false
+	}
+	s.shared.mutex.RLock()
+	defer s.shared.mutex.RUnlock()
+	handler, ok := s.shared.debug.selections[layer]
+	return handler, ok
+}
+
+// DisableComponent 让HandlerGroup、AsyncHandlerGroup、LayerCenter等组合组件跳过名为name的子组件
+func (s *GhGroupsContext) DisableComponent(name string) {
+	shared := s.sharedState()
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	if shared.debug.disabled == nil {
+		shared.debug.disabled = make(map[string]struct{})
+	}
+	shared.debug.disabled[name] = struct{}{}
+}
+
+func (s *GhGroupsContext) ComponentDisabled(name string) bool {
+	if s.shared == nil {
+		return false
+	}
+	s.shared.mutex.RLock()
+	defer s.shared.mutex.RUnlock()
+	_, ok := s.shared.debug.disabled[name]
+	return ok
+}
+
+// ParseDebugOverrides 解析调试请求头，格式为逗号分隔的条目：
+// layer=handler 强制Layer的选择，!name 跳过组件，例如 "layer_c=ExampleC1Handler,!ExampleDHandler"
+func (s *GhGroupsContext) ParseDebugOverrides(header string) error {
+	for _, item := range strings.Split(header, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.HasPrefix(item, "!") {
+			name := strings.TrimSpace(item[1:])
+			if name == "" {
+				return fmt.Errorf("debug override %q has no component name", item)
+			}
+			s.DisableComponent(name)
+			continue
+		}
+		layer, handler, ok := strings.Cut(item, "=")
+		layer, handler = strings.TrimSpace(layer), strings.TrimSpace(handler)
+		if !ok || layer == "" || handler == "" {
+			return fmt.Errorf("debug override %q is invalid, expect layer=handler or !name", item)
+		}
+		s.SetDebugSelection(layer, handler)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package ghgroupscontext
+
+// Exposure 记录一次请求中某个Layer选择的分支，用于实验分析
+// Forced表示分支不是由divider选择的，Reason记录选择的来源
+type Exposure struct {
+	Layer   string
+	Handler string
+	Forced  bool
+	Reason  string
+}
+
+func (s *GhGroupsContext) Expose(exposure Exposure) {
+	shared := s.sharedState()
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	shared.exposures = append(shared.exposures, exposure)
+}
+
+func (s *GhGroupsContext) Exposures() []Exposure {
+	if s.shared == nil {
+		return nil
+	}
+	s.shared.mutex.RLock()
+	defer s.shared.mutex.RUnlock()
+	return append([]Exposure(nil), s.shared.exposures...)
+}
@@ -0,0 +1,39 @@
+package ghgroupscontext
+
+// 全局holdout：落入holdout的请求在所有Layer中都不经过divider，直接使用对照组handler
+// LayerCenter判断请求是否在holdout中，Layer通过HoldoutSelection读取自己的对照组
+
+// SetHoldout 标记请求落入holdout，controls是Layer名称到对照组handler的映射
+// 请求经过多个LayerCenter时合并各自的controls，同一个Layer以先设置的为准
+func (s *GhGroupsContext) SetHoldout(controls map[string]string) {
+	shared := s.sharedState()
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	if shared.holdout == nil {
+		shared.holdout = make(map[string]string, len(controls))
+	}
+	for layer, control := range controls {
+		if _, ok := shared.holdout[layer]; !ok {
+			shared.holdout[layer] = control
+		}
+	}
+}
+
+func (s *GhGroupsContext) InHoldout() bool {
+	if s.shared == nil {
+		return false
+	}
+	s.shared.mutex.RLock()
+	defer s.shared.mutex.RUnlock()
+	return s.shared.holdout != nil
+}
+
+func (s *GhGroupsContext) HoldoutSelection(layer string) (string, bool) {
+	if s.shared == nil {
+		return "", false
+	}
+	s.shared.mutex.RLock()
+	defer s.shared.mutex.RUnlock()
+	handler, ok := s.shared.holdout[layer]
+	return handler, ok
+}
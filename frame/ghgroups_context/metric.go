@@ -0,0 +1,24 @@
+package ghgroupscontext
+
+// Metric 是handler在请求中上报的结果指标，例如是否竞得、收入、耗时
+// 请求结束后由outcome.Collector按本次请求的Exposure归到各个Layer的分支上
+type Metric struct {
+	Name  string
+	Value float64
+}
+
+func (s *GhGroupsContext) ReportMetric(name string, value float64) {
+	shared := s.sharedState()
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	shared.metrics = append(shared.metrics, Metric{Name: name, Value: value})
+}
+
+func (s *GhGroupsContext) Metrics() []Metric {
+	if s.shared == nil {
+		return nil
+	}
+	s.shared.mutex.RLock()
+	defer s.shared.mutex.RUnlock()
+	return append([]Metric(nil), s.shared.metrics...)
+}
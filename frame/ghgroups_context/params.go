@@ -0,0 +1,82 @@
+package ghgroupscontext
+
+import "math"
+
+// 参数实验：Layer选中的分支可以携带一组参数，下游handler通过类型化的getter读取
+// 参数键在整个请求中共享，不同Layer应该使用不同的键
+
+func (s *GhGroupsContext) SetParams(params map[string]any) {
+	shared := s.sharedState()
+	shared.mutex.Lock()
+	defer shared.mutex.Unlock()
+	if shared.params == nil {
+		shared.params = make(map[string]any, len(params))
+	}
+	for key, value := range params {
+		shared.params[key] = value
+	}
+}
+
+func (s *GhGroupsContext) Param(key string) (any, bool) {
+	if s.shared == nil {
+		return nil, false
+	}
+	s.shared.mutex.RLock()
+	defer s.shared.mutex.RUnlock()
+	value, ok := s.shared.params[key]
+	return value, ok
+}
+
+// ParamInt 返回整数参数，值为整数的浮点数也可以读取
+func (s *GhGroupsContext) ParamInt(key string) (int, bool) {
+	value, ok := s.Param(key)
+	if !ok {
+		return 0, false
+	}
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), true
+		}
+	}
+	return 0, false
+}
+
+// ParamFloat 返回浮点数参数，整数也可以读取
+func (s *GhGroupsContext) ParamFloat(key string) (float64, bool) {
+	value, ok := s.Param(key)
+	if !ok {
+		return 0, false
+	}
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func (s *GhGroupsContext) ParamString(key string) (string, bool) {
+	value, ok := s.Param(key)
+	if !ok {
+		return "", false
+	}
+	v, ok := value.(string)
+	return v, ok
+}
+
+func (s *GhGroupsContext) ParamBool(key string) (bool, bool) {
+	value, ok := s.Param(key)
+	if !ok {
+		return false, false
+	}
+	v, ok := value.(bool)
+	return v, ok
+}
@@ -0,0 +1,113 @@
+package ghgroupscontext
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TraceNode 记录一次请求经过的组件树，每个节点对应一次组件调用
+type TraceNode struct {
+	Name     string
+	Records  []TraceRecord
+	Children []*TraceNode
+}
+
+type TraceRecord struct {
+	Key   string
+	Value any
+}
+
+// EnableTrace 开启本次请求的运行时追踪，需要在调用根组件的Handle之前调用
+func (s *GhGroupsContext) EnableTrace() {
+	s.sharedState()
+	if s.traceNode == nil {
+		s.traceNode = &TraceNode{}
+	}
+}
+
+func (s *GhGroupsContext) TraceEnabled() bool {
+	return s.traceNode != nil
+}
+
+// TraceRoot 返回追踪树的根节点，未开启追踪时返回nil
+func (s *GhGroupsContext) TraceRoot() *TraceNode {
+	return s.traceNode
+}
+
+// EnterTrace 派生一个指向子节点的GhGroupsContext，派生出的对象与原对象共享请求状态
+// 未开启追踪时直接返回原对象
+func (s *GhGroupsContext) EnterTrace(name string) *GhGroupsContext {
+	if s.traceNode == nil {
+		return s
+	}
+	child := &TraceNode{Name: name}
+	s.shared.mutex.Lock()
+	s.traceNode.Children = append(s.traceNode.Children, child)
+	s.shared.mutex.Unlock()
+
+	derived := *s
+	derived.traceNode = child
+	return &derived
+}
+
+// Trace 在当前节点上记录一条键值对，未开启追踪时不做任何事
+func (s *GhGroupsContext) Trace(key string, value any) {
+	if s.traceNode == nil {
+		return
+	}
+	s.shared.mutex.Lock()
+	defer s.shared.mutex.Unlock()
+	s.traceNode.Records = append(s.traceNode.Records, TraceRecord{Key: key, Value: value})
+}
+
+// Find 深度优先查找第一个名称匹配的节点
+func (t *TraceNode) Find(name string) *TraceNode {
+	if t == nil {
+		return nil
+	}
+	if t.Name == name {
+		return t
+	}
+	for _, child := range t.Children {
+		if found := child.Find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Get 返回当前节点上最后一条该键的记录
+func (t *TraceNode) Get(key string) (any, bool) {
+	if t == nil {
+		return nil, false
+	}
+	for i := len(t.Records) - 1; i >= 0; i-- {
+		if t.Records[i].Key == key {
+			return t.Records[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+func (t *TraceNode) String() string {
+	var builder strings.Builder
+	t.write(&builder, -1)
+	return builder.String()
+}
+
+func (t *TraceNode) write(builder *strings.Builder, deepth int) {
+	if t == nil {
+		return
+	}
+	if deepth >= 0 {
+		builder.WriteString(strings.Repeat("\t", deepth))
+		builder.WriteString(t.Name)
+		for _, record := range t.Records {
+			builder.WriteString(fmt.Sprintf(" %s=%v", record.Key, record.Value))
+		}
+		builder.WriteString("\n")
+	}
+	for _, child := range t.Children {
+		child.write(builder, deepth+1)
+	}
+}
@@ -15,10 +15,27 @@ type HandlerGroupConf struct {
 	Handlers []string `yaml:"handlers"`
 }
 
+func init() {
+	frame.RegisterConfParser("HandlerGroup", func(configure []byte) error {
+		_, err := ParseConf(configure)
+		return err
+	})
+}
+
+// ParseConf 解析HandlerGroup的配置，不会构建其中的handler，Constructor在创建之前以同样的方式校验配置
+func ParseConf(configure []byte) (*HandlerGroupConf, error) {
+	conf := new(HandlerGroupConf)
+	if err := yaml.Unmarshal(configure, conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
 type HandlerGroup struct {
 	HandlerGroupInterface
 	conf                 *HandlerGroupConf
 	handlers             []frame.HandlerBaseInterface
+	handlersName         []string
 	constructorInterface frame.ConstructorInterface
 }
 
@@ -33,6 +50,9 @@ func NewHandlerGroup(constructor frame.ConstructorInterface) *HandlerGroup {
 // frame.HandlerBaseInterface
 func (h *HandlerGroup) Handle(context *ghgroupscontext.GhGroupsContext) bool {
 	for _, handler := range h.handlers {
+		if debughelper.SkipDisabled(h.constructorInterface, handler.Name(), context) {
+			continue
+		}
 		if debughelper.HandleWithShowDuration(handler, handler.Name(), context) {
 			continue
 		}
@@ -52,8 +72,7 @@ func (h *HandlerGroup) LoadConfigFromFile(confPath string) error {
 }
 
 func (h *HandlerGroup) LoadConfigFromMemory(configure []byte) error {
-	conf := new(HandlerGroupConf)
-	err := yaml.Unmarshal([]byte(configure), conf)
+	conf, err := ParseConf(configure)
 	if err != nil {
 		return err
 	}
@@ -69,10 +88,34 @@ func (h *HandlerGroup) Name() string {
 }
 
 func (h *HandlerGroup) Add(handlderInterface frame.HandlerBaseInterface) error {
+	return h.add(handlderInterface.Name(), handlderInterface)
+}
+
+// add 按引用的名称记录handler，Rebind按这个名称而不是handler当前的Name查找
+func (h *HandlerGroup) add(name string, handlderInterface frame.HandlerBaseInterface) error {
 	h.handlers = append(h.handlers, handlderInterface)
+	h.handlersName = append(h.handlersName, name)
 	return nil
 }
 
+// Rebind 把引用名称为name的handler替换为concrete，供Constructor.Override使用，不能与Handle并发调用
+// 按配置中引用的名称查找，之前Override的组件Name与name不同时也能再次替换
+func (h *HandlerGroup) Rebind(name string, concrete any) (bool, error) {
+	rebound := false
+	for i, handlerName := range h.handlersName {
+		if handlerName != name {
+			continue
+		}
+		handlerInterface, ok := concrete.(frame.HandlerBaseInterface)
+		if !ok {
+			return false, fmt.Errorf("handler %s is not frame.HandlerBaseInterface", name)
+		}
+		h.handlers[i] = handlerInterface
+		rebound = true
+	}
+	return rebound, nil
+}
+
 // ///////////////////////////////////////////////////////////////////////////////////////////////////////////////
 func (h *HandlerGroup) SetConstructorInterface(constructorInterface any) {
 	constructorInterfaceNew, ok := constructorInterface.(frame.ConstructorInterface)
@@ -95,7 +138,7 @@ func (h *HandlerGroup) initHandlers() error {
 			if handlerInterface, ok := someInterface.(frame.HandlerBaseInterface); !ok {
 				return fmt.Errorf("handler %s is not frame.HandlerBaseInterface", handlerName)
 			} else {
-				err = h.Add(handlerInterface)
+				err = h.add(handlerName, handlerInterface)
 				if err != nil {
 					return err
 				}
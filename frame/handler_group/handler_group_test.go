@@ -2,6 +2,7 @@ package handlergroup
 
 import (
 	"fmt"
+	"ghgroups/frame"
 	"os"
 	"path"
 	"reflect"
@@ -16,6 +17,7 @@ import (
 
 	"bou.ke/monkey"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
 )
 
 func TestLoadConfigFromFile(t *testing.T) {
@@ -146,3 +148,124 @@ func TestAdd(t *testing.T) {
 	assert.True(t, handlerGroup.Handle(context))
 	assert.True(t, called)
 }
+
+func TestDebugDisabled(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data")
+	assert.Nil(t, errGetWd)
+
+	handlersConfPath := path.Join(testDataPath, "handlers")
+	constructor := utils.BuildConstructor(handlersConfPath)
+	err := constructor.Register(reflect.TypeOf(samplehandler.SampleAutoConstructHandler{}))
+	assert.Nil(t, err)
+	err = constructor.ParseHandlerConfFolder(handlersConfPath)
+	assert.Nil(t, err)
+
+	handlerGroup := NewHandlerGroup(constructor)
+	err = handlerGroup.LoadConfigFromFile(path.Join(testDataPath, "valid.yaml"))
+	assert.Nil(t, err)
+
+	ctx := ghgroupscontext.NewGhGroupsContext(nil)
+	ctx.EnableTrace()
+	ctx.DisableComponent("sample_a")
+	assert.True(t, handlerGroup.Handle(ctx))
+	assert.Equal(t, "sample_a status=true\nsample_b status=true\n", ctx.TraceRoot().String())
+
+	constructor.EnableDebugOverrides(true)
+	ctx = ghgroupscontext.NewGhGroupsContext(nil)
+	ctx.EnableTrace()
+	ctx.DisableComponent("sample_a")
+	assert.True(t, handlerGroup.Handle(ctx))
+	assert.Equal(t, "sample_a disabled=true\nsample_b status=true\n", ctx.TraceRoot().String())
+}
+
+// scopeCounterCreated 记录每个scopeCounterHandler被创建的次数
+var scopeCounterCreated = make(map[string]int)
+
+type scopeCounterHandler struct {
+	name  string
+	count int
+}
+
+func (s *scopeCounterHandler) Name() string {
+	return s.name
+}
+
+func (s *scopeCounterHandler) LoadConfigFromMemory(configure []byte) error {
+	conf := struct {
+		Name string `yaml:"name"`
+	}{}
+	if err := yaml.Unmarshal(configure, &conf); err != nil {
+		return err
+	}
+	s.name = conf.Name
+	scopeCounterCreated[s.name]++
+	return nil
+}
+
+func (s *scopeCounterHandler) Handle(context *ghgroupscontext.GhGroupsContext) bool {
+	s.count++
+	context.SetAttribute(s.name, s.count)
+	return true
+}
+
+func (s *scopeCounterHandler) Reset() {
+	s.count = 0
+}
+
+func TestScopes(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data", "scopes")
+	assert.Nil(t, errGetWd)
+
+	constructor := utils.BuildConstructor(path.Join(testDataPath, "handlers"))
+	err := constructor.Register(reflect.TypeOf(scopeCounterHandler{}))
+	assert.Nil(t, err)
+
+	groupA := NewHandlerGroup(constructor)
+	assert.Nil(t, groupA.LoadConfigFromFile(path.Join(testDataPath, "group.yaml")))
+	groupB := NewHandlerGroup(constructor)
+	assert.Nil(t, groupB.LoadConfigFromFile(path.Join(testDataPath, "group.yaml")))
+
+	assert.Same(t, groupA.handlers[0], groupB.handlers[0])
+	assert.NotSame(t, groupA.handlers[1], groupB.handlers[1])
+	assert.Same(t, groupA.handlers[2], groupB.handlers[2])
+	// per_reference登记的实例就是第一个引用处的实例，之后每个引用处只创建一次
+	assert.Equal(t, 2, scopeCounterCreated["counter_reference"])
+	assert.Equal(t, 1, scopeCounterCreated["counter_shared"])
+
+	// 直接获取时返回登记的实例，不会创建新实例
+	registered, err := constructor.GetHandler("counter_reference")
+	assert.Nil(t, err)
+	assert.Same(t, groupA.handlers[1], registered)
+	concrete, err := constructor.GetConcrete("counter_reference")
+	assert.Nil(t, err)
+	assert.Same(t, registered, concrete)
+	assert.Equal(t, 2, scopeCounterCreated["counter_reference"])
+
+	counts := func(ctx *ghgroupscontext.GhGroupsContext) []any {
+		result := make([]any, 0, 3)
+		for _, name := range []string{"counter_shared", "counter_reference", "counter_request"} {
+			value, _ := ctx.GetAttribute(name)
+			result = append(result, value)
+		}
+		return result
+	}
+
+	ctx := ghgroupscontext.NewGhGroupsContext(nil)
+	assert.True(t, groupA.Handle(ctx))
+	assert.Equal(t, []any{1, 1, 1}, counts(ctx))
+
+	ctx = ghgroupscontext.NewGhGroupsContext(nil)
+	assert.True(t, groupA.Handle(ctx))
+	assert.Equal(t, []any{2, 2, 1}, counts(ctx))
+
+	ctx = ghgroupscontext.NewGhGroupsContext(nil)
+	assert.True(t, groupB.Handle(ctx))
+	assert.Equal(t, []any{3, 1, 1}, counts(ctx))
+
+	t.Run("Input=unknown_scope", func(t *testing.T) {
+		_, err := frame.ParseScope("per_call")
+		assert.ErrorContains(t, err, "unknown scope per_call")
+	})
+}
@@ -21,10 +21,16 @@ type ConcreteInterface interface {
 	Name() string
 }
 
+// ConcreteFunc 按配置创建组件，可以在创建时校验配置、从ConstructorInterface取得依赖
+type ConcreteFunc func(configure []byte, constructorInterface ConstructorInterface) (ConcreteInterface, error)
+
 type FactoryInterface interface {
-	Register(reflect.Type) error
+	Register(reflect.Type, ...string) error
+	RegisterFunc(string, ConcreteFunc, ...string) error
 	Create(string, []byte, any) (any, error)
+	New(string, []byte, any) (any, error)
 	Get(string) (ConcreteInterface, error)
+	Remove(string)
 }
 
 type HandlerBaseInterface interface {
@@ -37,6 +43,37 @@ type DividerBaseInterface interface {
 	Select(context *ghgroupscontext.GhGroupsContext) string
 }
 
+// MultiDividerInterface 是可选接口，实现了它的divider可以让Layer在一次请求中执行多个handler
+type MultiDividerInterface interface {
+	DividerBaseInterface
+	SelectMany(context *ghgroupscontext.GhGroupsContext) []string
+}
+
+// RewardRecorderInterface 是可选接口，divider实现它之后可以接收在线反馈，layer是使用该divider的Layer名称
+type RewardRecorderInterface interface {
+	RecordReward(layer string, handler string, reward float64) error
+}
+
+// LayerRewardRecorderInterface 是可选接口，Layer把反馈转交给自己的divider，调用方只需要知道layer名称
+type LayerRewardRecorderInterface interface {
+	RecordReward(handler string, reward float64) error
+}
+
+// DividerOutputsInterface 是可选接口，声明divider所有可能选择的handler名称，Layer构建时据此校验配置
+type DividerOutputsInterface interface {
+	Outputs() []string
+}
+
+// DividerBinderInterface 是可选接口，Layer构建完成后把自己的handler告诉divider，divider运行时更新分配时据此校验
+type DividerBinderInterface interface {
+	BindLayer(layer string, handlers []string) error
+}
+
+// SaltInterface 是可选接口，按流量单元哈希分流的divider实现它，LayerCenter据此检查同一domain中的Layer是否正交
+type SaltInterface interface {
+	Salt() string
+}
+
 type LayerBaseInterface interface {
 	HandlerBaseInterface
 }
@@ -75,10 +112,54 @@ type ConstructorInterface interface {
 	HandlerConstructorInterface
 	LayerCenterConstructorInterface
 	FactoryInterface
+	ResourceProviderInterface
 	CreateConcrete(string) error
 	GetConcrete(string) (any, error)
 }
 
+// RebinderInterface 是可选接口，组合组件实现它之后，Constructor.Override可以替换组合组件已经持有的子组件
+// 返回值表示组合组件是否引用了name
+type RebinderInterface interface {
+	Rebind(name string, concrete any) (bool, error)
+}
+
+// UnregisterInterface 是可选接口，子构建器实现它之后，Constructor.Unregister可以删除已经构建的组件
+type UnregisterInterface interface {
+	Unregister(name string) bool
+}
+
+// ExistInterface 是可选接口，子构建器实现它之后，Constructor判断组件是否已经构建时不会按配置创建新的实例
+type ExistInterface interface {
+	Exist(name string) bool
+}
+
+// HandlerScopeInterface 是可选接口，HandlerConstructor实现它之后，Constructor为per_reference的handler的每个引用处各创建一个实例
+type HandlerScopeInterface interface {
+	Scope(name string) Scope
+	NewReference(name string) (HandlerBaseInterface, error)
+}
+
+// ResourceProviderInterface 按名称提供共享资源（客户端、词典、模型等），资源在配置文件中以kind: resource声明
+type ResourceProviderInterface interface {
+	Resource(name string) (any, error)
+}
+
+// TypeResolverInterface 是可选接口，Factory实现它之后，Constructor.Validate不创建组件就可以检查类型
+// 通过构造函数注册的类型在创建之前不知道具体类型，返回nil
+type TypeResolverInterface interface {
+	ResolveType(concreteTypeName string) (reflect.Type, error)
+}
+
+// InjectorInterface 是可选接口，Factory创建组件后、加载配置前调用Inject为组件注入依赖
+type InjectorInterface interface {
+	Inject(concrete any) error
+}
+
+// DebugOverridesSwitchInterface 是可选接口，constructor通过它决定是否允许请求级别的调试覆盖
+type DebugOverridesSwitchInterface interface {
+	DebugOverridesEnabled() bool
+}
+
 type ConstructorSetterInterface interface {
 	SetConstructorInterface(any)
 }
@@ -110,6 +191,12 @@ type HandlerGroupBaseInterface interface {
 	HandlerBaseInterface
 }
 
+// HandlerGroupInterface 是HandlerGroup kind要求的接口，Constructor据此识别类型名称不以HandlerGroup开头的handler group
+type HandlerGroupInterface interface {
+	HandlerGroupBaseInterface
+	Add(HandlerBaseInterface) error
+}
+
 type AsyncHandlerGroupConstructorInterface interface {
 	GetAsyncHandlerGroup(name string) (AsyncHandlerGroupBaseInterface, error)
 	RegisterAsyncHandlerGroup(name string, handlerGroupInterface AsyncHandlerGroupBaseInterface) error
@@ -120,3 +207,10 @@ type AsyncHandlerGroupConstructorInterface interface {
 type AsyncHandlerGroupBaseInterface interface {
 	HandlerBaseInterface
 }
+
+// AsyncHandlerGroupInterface 是AsyncHandlerGroup kind要求的接口，Concurrent用来与HandlerGroupInterface区分
+type AsyncHandlerGroupInterface interface {
+	AsyncHandlerGroupBaseInterface
+	Add(HandlerBaseInterface) error
+	Concurrent() bool
+}
@@ -0,0 +1,134 @@
+package frame
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Kind 描述一类组件。Constructor按Kind识别组件类型、校验组件实现的接口、解析配置并找出配置中引用的子组件
+// 内置的Handler、Divider、Layer、LayerCenter、HandlerGroup、AsyncHandlerGroup也以Kind的形式注册
+type Kind struct {
+	// Name kind的名称，类型名称与Name相同时一定属于该kind
+	Name string
+	// Interface 组件必须实现的接口，例如 reflect.TypeOf((*frame.HandlerBaseInterface)(nil)).Elem()
+	Interface reflect.Type
+	// Match 判断类型名称是否属于该kind，Match为空时只有与Name相同的类型名称属于该kind
+	// 类型名称优先于Interface；类型名称不匹配任何kind时才按实现的Interface归类，配置文件中的kind字段优先于两者
+	Match func(typeName string) bool
+	// ParseConf 在创建组件之前解析并校验配置，为空时只检查yaml格式
+	ParseConf func(configure []byte) error
+	// ChildFields 配置中引用子组件名称的字段，值可以是字符串或字符串列表，嵌套的字段用.连接，例如 domains.layers
+	ChildFields []string
+	// ChildInterfaces 字段引用的子组件必须实现的接口，Validate据此检查引用的组件是否属于正确的kind
+	ChildInterfaces map[string]reflect.Type
+}
+
+// Matches 判断类型名称是否属于该kind
+func (k *Kind) Matches(typeName string) bool {
+	if typeName == k.Name {
+		return true
+	}
+	return k.Match != nil && k.Match(typeName)
+}
+
+// Implements 判断组件是否实现了该kind要求的接口
+func (k *Kind) Implements(concrete any) bool {
+	if k.Interface == nil {
+		return true
+	}
+	return concrete != nil && reflect.TypeOf(concrete).Implements(k.Interface)
+}
+
+// Parse 用ParseConf解析配置，没有ParseConf时只检查yaml格式
+func (k *Kind) Parse(configure []byte) error {
+	if k.ParseConf != nil {
+		return k.ParseConf(configure)
+	}
+	conf := make(map[any]any)
+	return yaml.Unmarshal(configure, &conf)
+}
+
+// Children 按ChildFields从配置中取出引用的子组件名称，保持配置中的顺序
+func (k *Kind) Children(configure []byte) ([]string, error) {
+	if len(k.ChildFields) == 0 {
+		return nil, nil
+	}
+	children := make([]string, 0)
+	for _, field := range k.ChildFields {
+		names, err := k.FieldChildren(configure, field)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, names...)
+	}
+	return children, nil
+}
+
+// FieldChildren 取出配置中某一个字段引用的子组件名称
+func (k *Kind) FieldChildren(configure []byte, field string) ([]string, error) {
+	conf := make(map[any]any)
+	if err := yaml.Unmarshal(configure, &conf); err != nil {
+		return nil, err
+	}
+	names, err := childNames(conf, strings.Split(field, "."))
+	if err != nil {
+		return nil, fmt.Errorf("kind %s field %s: %v", k.Name, field, err)
+	}
+	return names, nil
+}
+
+func childNames(value any, path []string) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if len(path) > 0 {
+			return nil, fmt.Errorf("%q is not a map", v)
+		}
+		if v == "" {
+			return nil, nil
+		}
+		return []string{v}, nil
+	case []any:
+		names := make([]string, 0, len(v))
+		for _, item := range v {
+			itemNames, err := childNames(item, path)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, itemNames...)
+		}
+		return names, nil
+	case map[any]any:
+		if len(path) == 0 {
+			return nil, fmt.Errorf("%v is not a name", v)
+		}
+		return childNames(v[path[0]], path[1:])
+	}
+	return nil, fmt.Errorf("%v is not a name", value)
+}
+
+var (
+	confParsersMutex sync.Mutex
+	confParsers      = make(map[string]func(configure []byte) error)
+)
+
+// RegisterConfParser 登记内置kind的配置校验函数，由实现该kind的包在init中调用
+// 校验函数与组件的LoadConfigFromMemory共用同一份代码，Constructor在创建组件之前和Validate时调用它
+func RegisterConfParser(kindName string, parseConf func(configure []byte) error) {
+	confParsersMutex.Lock()
+	defer confParsersMutex.Unlock()
+	confParsers[kindName] = parseConf
+}
+
+// ConfParser 返回为kind登记的配置校验函数
+func ConfParser(kindName string) (func(configure []byte) error, bool) {
+	confParsersMutex.Lock()
+	defer confParsersMutex.Unlock()
+	parseConf, ok := confParsers[kindName]
+	return parseConf, ok
+}
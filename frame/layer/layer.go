@@ -6,23 +6,153 @@ import (
 	debughelper "ghgroups/frame/debug_helper"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"os"
+	"sync"
+	"sync/atomic"
 
 	"gopkg.in/yaml.v2"
 )
 
 // /////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 type LayerConf struct {
-	Name     string   `yaml:"name"`
-	Divider  string   `yaml:"divider"`
-	Handlers []string `yaml:"handlers"`
+	Name      string                    `yaml:"name"`
+	Divider   string                    `yaml:"divider"`
+	Handlers  []string                  `yaml:"handlers"`
+	Default   string                    `yaml:"default"`
+	OnUnknown string                    `yaml:"on_unknown"`
+	Mode      string                    `yaml:"mode"`
+	Overrides OverridesConf             `yaml:"overrides"`
+	Control   string                    `yaml:"control"`
+	Params    map[string]map[string]any `yaml:"params"`
+	Schedules map[string]ScheduleConf   `yaml:"schedules"`
+}
+
+// divider实现了frame.MultiDividerInterface时，被选中的多个handler的执行方式，默认串行
+const (
+	ModeSequential = "sequential"
+	ModeParallel   = "parallel"
+)
+
+// divider选择了不存在的handler时的处理方式
+// 未配置on_unknown时，配置了default则使用default，否则使用fail
+const (
+	OnUnknownFail    = "fail"
+	OnUnknownSkip    = "skip"
+	OnUnknownDefault = "default"
+)
+
+// 写入GhGroupsContext的Exposure中的选择来源
+const (
+	ReasonDivider  = "divider"
+	ReasonDefault  = "default"
+	ReasonOverride = "override"
+	ReasonDebug    = "debug"
+	ReasonHoldout  = "holdout"
+	ReasonSchedule = "schedule"
+)
+
+// UnknownSelectionError 在divider选择了Layer中不存在的handler时上报到GhGroupsContext
+type UnknownSelectionError struct {
+	Layer     string
+	Selection string
+	OnUnknown string
+}
+
+func (e *UnknownSelectionError) Error() string {
+	return fmt.Sprintf("layer %s: divider selected unknown handler %q, on_unknown is %s", e.Layer, e.Selection, e.OnUnknown)
+}
+
+func init() {
+	frame.RegisterConfParser("Layer", func(configure []byte) error {
+		_, err := ParseConf(configure)
+		return err
+	})
+}
+
+// ParseConf 解析并校验Layer的配置，不会构建divider和handler，Constructor在创建Layer之前以同样的方式校验配置
+func ParseConf(configure []byte) (LayerConf, error) {
+	var layerConf LayerConf
+	if err := yaml.Unmarshal(configure, &layerConf); err != nil {
+		return layerConf, err
+	}
+	return layerConf, layerConf.Check()
+}
+
+// Check 校验不依赖divider和handler实例的配置，handlers和params中的名称都是Layer的分支
+func (c *LayerConf) Check() error {
+	switch c.Mode {
+	case "", ModeSequential, ModeParallel:
+	default:
+		return fmt.Errorf("layer %s mode %s is invalid, must be sequential or parallel", c.Name, c.Mode)
+	}
+
+	variants := make(map[string]bool, len(c.Handlers)+len(c.Params))
+	for _, handlerName := range c.Handlers {
+		variants[handlerName] = true
+	}
+	for variantName, params := range c.Params {
+		if len(params) == 0 {
+			return fmt.Errorf("layer %s params %s is empty", c.Name, variantName)
+		}
+		variants[variantName] = true
+	}
+
+	switch c.OnUnknown {
+	case "":
+	case OnUnknownFail, OnUnknownSkip:
+	case OnUnknownDefault:
+		if c.Default == "" {
+			return fmt.Errorf("layer %s on_unknown is default but default is not set", c.Name)
+		}
+	default:
+		return fmt.Errorf("layer %s on_unknown %s is invalid, must be fail, skip or default", c.Name, c.OnUnknown)
+	}
+	if c.Default != "" && !variants[c.Default] {
+		return fmt.Errorf("layer %s default %s is not in handlers", c.Name, c.Default)
+	}
+
+	if len(c.Schedules) > 0 && c.Default == "" {
+		return fmt.Errorf("layer %s has schedules but default is not set", c.Name)
+	}
+	for variantName, scheduleConf := range c.Schedules {
+		if !variants[variantName] {
+			return fmt.Errorf("layer %s schedule %s is not in handlers", c.Name, variantName)
+		}
+		if variantName == c.Default {
+			return fmt.Errorf("layer %s default %s can not have schedule", c.Name, variantName)
+		}
+		if _, err := newSchedule(scheduleConf); err != nil {
+			return fmt.Errorf("layer %s schedule %s: %v", c.Name, variantName, err)
+		}
+	}
+
+	if len(c.Overrides.IDs) > 0 && c.Overrides.Attribute == "" {
+		return fmt.Errorf("layer %s overrides must have attribute", c.Name)
+	}
+	for id, handlerName := range c.Overrides.IDs {
+		if !variants[handlerName] {
+			return fmt.Errorf("layer %s override %s for %s is not in handlers", c.Name, handlerName, id)
+		}
+	}
+
+	if c.Control != "" && !variants[c.Control] {
+		return fmt.Errorf("layer %s control %s is not in handlers", c.Name, c.Control)
+	}
+	return nil
 }
 
 type Layer struct {
 	frame.LayerWithBuilderInterface
 	conf                 LayerConf
 	divider              frame.DividerBaseInterface
+	multiDivider         frame.MultiDividerInterface
 	handlers             map[string]frame.HandlerBaseInterface
 	constructorInterface frame.ConstructorInterface
+	unknownSelections    uint64
+	warnings             []string
+	warningsMutex        sync.Mutex
+	schedules            map[string]*schedule
+	clock                Clock
+	overrides            atomic.Pointer[OverridesConf]
 }
 
 func NewLayer(name string, constructorInterface frame.ConstructorInterface) *Layer {
@@ -30,12 +160,14 @@ func NewLayer(name string, constructorInterface frame.ConstructorInterface) *Lay
 		constructorInterface: constructorInterface,
 		conf:                 LayerConf{Name: name},
 		handlers:             make(map[string]frame.HandlerBaseInterface),
+		clock:                systemClock{},
 	}
 }
 
 func (l *Layer) SetDivider(name string, t frame.DividerBaseInterface) error {
 	if l.divider == nil {
 		l.divider = t
+		l.multiDivider, _ = t.(frame.MultiDividerInterface)
 	} else {
 		return fmt.Errorf("divider %s already exists", name)
 	}
@@ -50,6 +182,54 @@ func (l *Layer) AddHandler(name string, h frame.HandlerBaseInterface) error {
 	return nil
 }
 
+// Rebind 把已经持有的名为name的divider或handler替换为concrete，供Constructor.Override使用，不能与Handle并发调用
+func (l *Layer) Rebind(name string, concrete any) (bool, error) {
+	rebound := false
+	if l.conf.Divider == name {
+		dividerInterface, ok := concrete.(frame.DividerBaseInterface)
+		if !ok {
+			return false, fmt.Errorf("divider %s is not frame.DividerBaseInterface", name)
+		}
+		l.divider = dividerInterface
+		l.multiDivider, _ = dividerInterface.(frame.MultiDividerInterface)
+		if err := l.bindDivider(); err != nil {
+			return false, err
+		}
+		rebound = true
+	}
+	if handler, ok := l.handlers[name]; ok {
+		handlerInterface, ok := concrete.(frame.HandlerBaseInterface)
+		if !ok {
+			return false, fmt.Errorf("handler %s is not frame.HandlerBaseInterface", name)
+		}
+		// 配置了params的分支由paramsHandler包装，只替换被包装的handler
+		if params, ok := handler.(*paramsHandler); ok {
+			params.handler = handlerInterface
+		} else {
+			l.handlers[name] = handlerInterface
+		}
+		rebound = true
+	}
+	return rebound, nil
+}
+
+// RecordReward 把handler的一次收益转交给divider，divider不接收反馈时返回错误
+func (l *Layer) RecordReward(handler string, reward float64) error {
+	rewardRecorderInterface, ok := l.divider.(frame.RewardRecorderInterface)
+	if !ok {
+		return fmt.Errorf("layer %s divider %s does not record rewards", l.conf.Name, l.conf.Divider)
+	}
+	return rewardRecorderInterface.RecordReward(l.conf.Name, handler, reward)
+}
+
+// Salt 返回divider哈希分流使用的salt，divider不按哈希分流时返回空字符串
+func (l *Layer) Salt() string {
+	if saltInterface, ok := l.divider.(frame.SaltInterface); ok {
+		return saltInterface.Salt()
+	}
+	return ""
+}
+
 func (l *Layer) initDivider(dividerName string) error {
 	if err := l.constructorInterface.CreateConcrete(dividerName); err != nil {
 		return err
@@ -97,7 +277,16 @@ func (l *Layer) init() error {
 	if l.handlers == nil {
 		l.handlers = make(map[string]frame.HandlerBaseInterface)
 	}
-	err := l.initDivider(l.conf.Divider)
+	if l.clock == nil {
+		l.clock = systemClock{}
+	}
+	// 先校验配置，配置有误时不会构建任何子组件
+	err := l.conf.Check()
+	if err != nil {
+		return err
+	}
+
+	err = l.initDivider(l.conf.Divider)
 	if err != nil {
 		return err
 	}
@@ -106,9 +295,98 @@ func (l *Layer) init() error {
 	if err != nil {
 		return err
 	}
+
+	err = l.initParams()
+	if err != nil {
+		return err
+	}
+
+	err = l.initSchedules()
+	if err != nil {
+		return err
+	}
+
+	err = l.SetOverrides(l.conf.Overrides)
+	if err != nil {
+		return err
+	}
+
+	err = l.checkDividerOutputs()
+	if err != nil {
+		return err
+	}
+	return l.bindDivider()
+}
+
+// bindDivider 让支持运行时更新分配的divider知道本Layer有哪些handler
+func (l *Layer) bindDivider() error {
+	dividerBinderInterface, ok := l.divider.(frame.DividerBinderInterface)
+	if !ok {
+		return nil
+	}
+	handlersName := make([]string, 0, len(l.handlers))
+	for handlerName := range l.handlers {
+		handlersName = append(handlersName, handlerName)
+	}
+	return dividerBinderInterface.BindLayer(l.conf.Name, handlersName)
+}
+
+// checkDividerOutputs 要求divider声明的每个输出都有对应的handler，不会被选中的handler只给出警告
+func (l *Layer) checkDividerOutputs() error {
+	dividerOutputsInterface, ok := l.divider.(frame.DividerOutputsInterface)
+	if !ok {
+		return nil
+	}
+	outputs := make(map[string]struct{})
+	for _, output := range dividerOutputsInterface.Outputs() {
+		if _, ok := l.handlers[output]; !ok {
+			return fmt.Errorf("layer %s divider %s may select %s which is not in handlers", l.conf.Name, l.conf.Divider, output)
+		}
+		outputs[output] = struct{}{}
+	}
+	for _, handlerName := range l.variantsName() {
+		if _, ok := outputs[handlerName]; ok || handlerName == l.conf.Default {
+			continue
+		}
+		l.warn(fmt.Sprintf("layer %s handler %s can never be selected by divider %s", l.conf.Name, handlerName, l.conf.Divider))
+	}
 	return nil
 }
 
+// warn 只记录警告，由调用方通过Warnings决定如何输出，Layer本身不写标准输出
+func (l *Layer) warn(warning string) {
+	l.warningsMutex.Lock()
+	l.warnings = append(l.warnings, warning)
+	l.warningsMutex.Unlock()
+}
+
+func (l *Layer) HasHandler(name string) bool {
+	_, ok := l.handlers[name]
+	return ok
+}
+
+// Control 返回配置的对照组handler，实验结果以它为基准比较
+func (l *Layer) Control() string {
+	return l.conf.Control
+}
+
+// Warnings 返回构建和运行过程中发现的不影响运行的配置问题
+func (l *Layer) Warnings() []string {
+	l.warningsMutex.Lock()
+	defer l.warningsMutex.Unlock()
+	return append([]string(nil), l.warnings...)
+}
+
+func (l *Layer) onUnknown() string {
+	if l.conf.OnUnknown != "" {
+		return l.conf.OnUnknown
+	}
+	if l.conf.Default != "" {
+		return OnUnknownDefault
+	}
+	return OnUnknownFail
+}
+
 func (l *Layer) SetConstructorInterface(constructorInterface any) {
 	constructorInterfaceNew, ok := constructorInterface.(frame.ConstructorInterface)
 	if !ok {
@@ -123,11 +401,127 @@ func (l *Layer) Name() string {
 }
 
 func (l *Layer) Handle(ctx *ghgroupscontext.GhGroupsContext) bool {
-	layerName := l.divider.Select(ctx)
-	if handler, ok := l.handlers[layerName]; !ok {
-		return false
+	if handlerName, reason, ok := l.forcedSelection(ctx); ok {
+		ctx.Trace("forced", reason)
+		return l.handleSelected(ctx, handlerName, true, reason)
+	}
+	if l.multiDivider != nil {
+		return l.handleMany(ctx, l.multiDivider.SelectMany(ctx))
+	}
+	handlerName, reason := l.scheduled(ctx, l.divider.Select(ctx))
+	return l.handleSelected(ctx, handlerName, false, reason)
+}
+
+// forcedSelection 返回不经过divider直接指定的handler，优先级依次为调试覆盖、白名单、holdout
+func (l *Layer) forcedSelection(ctx *ghgroupscontext.GhGroupsContext) (string, string, bool) {
+	if debughelper.DebugOverridesEnabled(l.constructorInterface) {
+		if handlerName, ok := ctx.DebugSelection(l.Name()); ok {
+			return handlerName, ReasonDebug, true
+		}
+	}
+	if handlerName, ok := l.overrideSelection(ctx); ok {
+		return handlerName, ReasonOverride, true
+	}
+	if handlerName, ok := ctx.HoldoutSelection(l.Name()); ok {
+		return handlerName, ReasonHoldout, true
+	}
+	return "", "", false
+}
+
+func (l *Layer) handleSelected(ctx *ghgroupscontext.GhGroupsContext, handlerName string, forced bool, reason string) bool {
+	if handler, ok := l.handlers[handlerName]; !ok {
+		return l.handleUnknown(ctx, handlerName)
 	} else {
-		return debughelper.HandleWithShowDuration(handler, layerName, ctx)
+		ctx.Expose(ghgroupscontext.Exposure{Layer: l.Name(), Handler: handlerName, Forced: forced, Reason: reason})
+		return debughelper.HandleWithShowDuration(handler, handlerName, ctx)
+	}
+}
+
+// UnknownSelections 返回divider选择了不存在的handler的累计次数
+func (l *Layer) UnknownSelections() uint64 {
+	return atomic.LoadUint64(&l.unknownSelections)
+}
+
+func (l *Layer) handleUnknown(ctx *ghgroupscontext.GhGroupsContext, selection string) bool {
+	switch l.reportUnknown(ctx, selection) {
+	case OnUnknownSkip:
+		return true
+	case OnUnknownDefault:
+		ctx.Expose(ghgroupscontext.Exposure{Layer: l.Name(), Handler: l.conf.Default, Reason: ReasonDefault})
+		return debughelper.HandleWithShowDuration(l.handlers[l.conf.Default], l.conf.Default, ctx)
+	default:
+		return false
+	}
+}
+
+func (l *Layer) reportUnknown(ctx *ghgroupscontext.GhGroupsContext, selection string) string {
+	atomic.AddUint64(&l.unknownSelections, 1)
+	onUnknown := l.onUnknown()
+	ctx.ReportError(&UnknownSelectionError{Layer: l.Name(), Selection: selection, OnUnknown: onUnknown})
+	ctx.Trace("unknown_selection", selection)
+	return onUnknown
+}
+
+func (l *Layer) handleMany(ctx *ghgroupscontext.GhGroupsContext, handlersName []string) bool {
+	names := make([]string, 0, len(handlersName))
+	handlers := make([]frame.HandlerBaseInterface, 0, len(handlersName))
+	selected := make(map[string]struct{}, len(handlersName))
+	for _, selectedName := range handlersName {
+		handlerName, reason := l.scheduled(ctx, selectedName)
+		handler, ok := l.handlers[handlerName]
+		if !ok {
+			switch l.reportUnknown(ctx, handlerName) {
+			case OnUnknownSkip:
+				continue
+			case OnUnknownDefault:
+				handlerName = l.conf.Default
+				handler = l.handlers[handlerName]
+				reason = ReasonDefault
+			default:
+				return false
+			}
+		}
+		if _, ok := selected[handlerName]; ok {
+			continue
+		}
+		selected[handlerName] = struct{}{}
+		names = append(names, handlerName)
+		handlers = append(handlers, handler)
+		ctx.Expose(ghgroupscontext.Exposure{Layer: l.Name(), Handler: handlerName, Reason: reason})
+	}
+
+	if l.conf.Mode == ModeParallel {
+		return l.handleParallel(ctx, names, handlers)
+	}
+	for i, handler := range handlers {
+		if !debughelper.HandleWithShowDuration(handler, names[i], ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *Layer) handleParallel(ctx *ghgroupscontext.GhGroupsContext, names []string, handlers []frame.HandlerBaseInterface) bool {
+	wg := sync.WaitGroup{}
+	checkChan := make(chan bool, len(handlers))
+	for i, handler := range handlers {
+		wg.Add(1)
+		go func(name string, handler frame.HandlerBaseInterface) {
+			status := debughelper.HandleWithShowDuration(handler, name, ctx)
+			checkChan <- status
+			wg.Done()
+		}(names[i], handler)
+	}
+	wg.Wait()
+	for {
+		select {
+		case status := <-checkChan:
+			if !status {
+				return false
+			}
+		default:
+			return true
+		}
 	}
 }
 
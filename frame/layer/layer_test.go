@@ -1,7 +1,10 @@
 package layer
 
 import (
+	"errors"
 	"fmt"
+	"ghgroups/frame"
+	"ghgroups/frame/constructor"
 	dividerconstructor "ghgroups/frame/constructor/divider_constructor"
 	handlerconstructor "ghgroups/frame/constructor/handler_constructor"
 	layerconstructor "ghgroups/frame/constructor/layer_constructor"
@@ -11,7 +14,9 @@ import (
 	"path"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	sampledivider "ghgroups/frame/sample_divider"
@@ -213,3 +218,469 @@ func TestSelfAutoConstruct(t *testing.T) {
 	suc := layerBaseInterface.Handle(&context)
 	assert.True(t, suc)
 }
+
+func TestUnknownSelection(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data")
+	assert.Nil(t, errGetWd)
+
+	constructor := utils.BuildConstructor("")
+	constructor.Register(reflect.TypeOf(sampledivider.SampleAutoConstructDivider{}))
+	constructor.Register(reflect.TypeOf(samplehandler.SampleAutoConstructHandler{}))
+	err := constructor.ParseHandlerConfFolder(path.Join(testDataPath, "handlers"))
+	assert.Nil(t, err)
+	err = constructor.ParseDividerConfFolder(path.Join(testDataPath, "divider"))
+	assert.Nil(t, err)
+
+	cases := []struct {
+		name      string
+		status    bool
+		onUnknown string
+		handled   string
+	}{
+		{"Input=unknown/fail.yaml", false, OnUnknownFail, ""},
+		{"Input=unknown/skip.yaml", true, OnUnknownSkip, ""},
+		{"Input=unknown/default.yaml", true, OnUnknownDefault, "handler_sample_a"},
+		{"Input=unknown/default_explicit.yaml", true, OnUnknownDefault, "handler_sample_a"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testName := t.Name()
+			comma := strings.Index(testName, "=")
+			assert.Greater(t, comma, 0)
+			confName := testName[comma+1:]
+			confPath := path.Join(testDataPath, confName)
+			assert.FileExists(t, confPath)
+
+			layer := NewLayer("", constructor)
+			err := layer.LoadConfigFromFile(confPath)
+			assert.Nil(t, err)
+
+			ctx := ghgroupscontext.NewGhGroupsContext(nil)
+			ctx.EnableTrace()
+			assert.Equal(t, c.status, layer.Handle(ctx))
+			assert.Equal(t, uint64(1), layer.UnknownSelections())
+
+			errs := ctx.Errors()
+			assert.Len(t, errs, 1)
+			var unknownSelectionError *UnknownSelectionError
+			assert.True(t, errors.As(errs[0], &unknownSelectionError))
+			assert.Equal(t, layer.Name(), unknownSelectionError.Layer)
+			assert.Equal(t, "handler_not_exist", unknownSelectionError.Selection)
+			assert.Equal(t, c.onUnknown, unknownSelectionError.OnUnknown)
+
+			if c.handled != "" {
+				assert.NotNil(t, ctx.TraceRoot().Find(c.handled))
+			} else {
+				assert.Empty(t, ctx.TraceRoot().Children)
+			}
+		})
+	}
+
+	invalidCases := map[string]string{
+		"Input=unknown/default_not_set.yaml":         "on_unknown is default but default is not set",
+		"Input=unknown/invalid_on_unknown.yaml":      "on_unknown retry is invalid",
+		"Input=unknown/default_not_in_handlers.yaml": "default handler_sample_c is not in handlers",
+	}
+	for name, expected := range invalidCases {
+		t.Run(name, func(t *testing.T) {
+			testName := t.Name()
+			comma := strings.Index(testName, "=")
+			assert.Greater(t, comma, 0)
+			confName := testName[comma+1:]
+			confPath := path.Join(testDataPath, confName)
+			assert.FileExists(t, confPath)
+
+			layer := NewLayer("", constructor)
+			err := layer.LoadConfigFromFile(confPath)
+			assert.ErrorContains(t, err, expected)
+		})
+	}
+}
+
+type testMultiDivider struct {
+	frame.DividerBaseInterface
+	selections []string
+}
+
+func (d *testMultiDivider) Name() string {
+	return "test_multi_divider"
+}
+
+func (d *testMultiDivider) Select(context *ghgroupscontext.GhGroupsContext) string {
+	return d.selections[0]
+}
+
+func (d *testMultiDivider) SelectMany(context *ghgroupscontext.GhGroupsContext) []string {
+	return d.selections
+}
+
+type testRecordHandler struct {
+	frame.HandlerBaseInterface
+	name   string
+	status bool
+	delay  time.Duration
+	calls  int32
+}
+
+func (h *testRecordHandler) Name() string {
+	return h.name
+}
+
+func (h *testRecordHandler) Handle(context *ghgroupscontext.GhGroupsContext) bool {
+	time.Sleep(h.delay)
+	atomic.AddInt32(&h.calls, 1)
+	return h.status
+}
+
+func TestMultiDivider(t *testing.T) {
+	newLayer := func(t *testing.T, mode string, selections []string, statuses map[string]bool) (*Layer, map[string]*testRecordHandler) {
+		constructor := utils.BuildConstructor("")
+		handlers := make(map[string]*testRecordHandler)
+		for name, status := range statuses {
+			handler := &testRecordHandler{name: name, status: status, delay: 50 * time.Millisecond}
+			handlers[name] = handler
+			assert.Nil(t, constructor.RegisterHandler(name, handler))
+		}
+		assert.Nil(t, constructor.RegisterDivider("test_multi_divider", &testMultiDivider{selections: selections}))
+
+		conf := fmt.Sprintf("name: multi_layer\ndivider: test_multi_divider\nmode: %s\ndefault: base\nhandlers:\n  - base\n  - rerank\n  - broken\n", mode)
+		layer := NewLayer("", constructor)
+		assert.Nil(t, layer.LoadConfigFromMemory([]byte(conf)))
+		return layer, handlers
+	}
+	statuses := map[string]bool{"base": true, "rerank": true, "broken": false}
+
+	t.Run("Input=sequential", func(t *testing.T) {
+		layer, handlers := newLayer(t, ModeSequential, []string{"base", "rerank"}, statuses)
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		ctx.EnableTrace()
+		assert.True(t, layer.Handle(ctx))
+		assert.Equal(t, int32(1), handlers["base"].calls)
+		assert.Equal(t, int32(1), handlers["rerank"].calls)
+		assert.Equal(t, "base status=true\nrerank status=true\n", ctx.TraceRoot().String())
+	})
+
+	t.Run("Input=sequential_stop_at_failure", func(t *testing.T) {
+		layer, handlers := newLayer(t, ModeSequential, []string{"broken", "rerank"}, statuses)
+		assert.False(t, layer.Handle(ghgroupscontext.NewGhGroupsContext(nil)))
+		assert.Equal(t, int32(1), handlers["broken"].calls)
+		assert.Equal(t, int32(0), handlers["rerank"].calls)
+	})
+
+	t.Run("Input=parallel", func(t *testing.T) {
+		layer, handlers := newLayer(t, ModeParallel, []string{"base", "rerank", "broken"}, statuses)
+		start := time.Now()
+		assert.False(t, layer.Handle(ghgroupscontext.NewGhGroupsContext(nil)))
+		assert.Less(t, time.Since(start), 140*time.Millisecond)
+		for _, handler := range handlers {
+			assert.Equal(t, int32(1), handler.calls)
+		}
+	})
+
+	t.Run("Input=unknown_to_default", func(t *testing.T) {
+		layer, handlers := newLayer(t, ModeParallel, []string{"not_exist", "base", "rerank"}, statuses)
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		assert.True(t, layer.Handle(ctx))
+		assert.Equal(t, int32(1), handlers["base"].calls)
+		assert.Equal(t, int32(1), handlers["rerank"].calls)
+		assert.Len(t, ctx.Errors(), 1)
+	})
+
+	t.Run("Input=invalid_mode", func(t *testing.T) {
+		constructor := utils.BuildConstructor("")
+		layer := NewLayer("", constructor)
+		err := layer.LoadConfigFromMemory([]byte("name: multi_layer\ndivider: test_multi_divider\nmode: random\n"))
+		assert.ErrorContains(t, err, "mode random is invalid")
+	})
+}
+
+type testOutputsDivider struct {
+	frame.DividerBaseInterface
+	outputs []string
+}
+
+func (d *testOutputsDivider) Name() string {
+	return "test_outputs_divider"
+}
+
+func (d *testOutputsDivider) Select(context *ghgroupscontext.GhGroupsContext) string {
+	return d.outputs[0]
+}
+
+func (d *testOutputsDivider) Outputs() []string {
+	return d.outputs
+}
+
+func TestDividerOutputs(t *testing.T) {
+	newConstructor := func(t *testing.T, outputs []string) *constructor.Constructor {
+		constructor := utils.BuildConstructor("")
+		for _, name := range []string{"handler_x", "handler_y", "handler_z"} {
+			assert.Nil(t, constructor.RegisterHandler(name, &testRecordHandler{name: name, status: true}))
+		}
+		assert.Nil(t, constructor.RegisterDivider("test_outputs_divider", &testOutputsDivider{outputs: outputs}))
+		return constructor
+	}
+
+	t.Run("Input=all_selectable", func(t *testing.T) {
+		layer := NewLayer("", newConstructor(t, []string{"handler_x", "handler_y"}))
+		err := layer.LoadConfigFromMemory([]byte("name: outputs_layer\ndivider: test_outputs_divider\nhandlers:\n  - handler_x\n  - handler_y\n"))
+		assert.Nil(t, err)
+		assert.Empty(t, layer.Warnings())
+	})
+
+	t.Run("Input=output_without_handler", func(t *testing.T) {
+		layer := NewLayer("", newConstructor(t, []string{"handler_x", "handler_z"}))
+		err := layer.LoadConfigFromMemory([]byte("name: outputs_layer\ndivider: test_outputs_divider\nhandlers:\n  - handler_x\n  - handler_y\n"))
+		assert.ErrorContains(t, err, "layer outputs_layer divider test_outputs_divider may select handler_z which is not in handlers")
+	})
+
+	t.Run("Input=handler_never_selected", func(t *testing.T) {
+		layer := NewLayer("", newConstructor(t, []string{"handler_x"}))
+		err := layer.LoadConfigFromMemory([]byte("name: outputs_layer\ndivider: test_outputs_divider\ndefault: handler_z\nhandlers:\n  - handler_x\n  - handler_y\n  - handler_z\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"layer outputs_layer handler handler_y can never be selected by divider test_outputs_divider"}, layer.Warnings())
+	})
+}
+
+func TestOverrides(t *testing.T) {
+	newLayer := func(t *testing.T, conf string) (*Layer, map[string]*testRecordHandler) {
+		constructor := utils.BuildConstructor("")
+		handlers := make(map[string]*testRecordHandler)
+		for _, name := range []string{"handler_x", "handler_y"} {
+			handlers[name] = &testRecordHandler{name: name, status: true}
+			assert.Nil(t, constructor.RegisterHandler(name, handlers[name]))
+		}
+		assert.Nil(t, constructor.RegisterDivider("test_outputs_divider", &testOutputsDivider{outputs: []string{"handler_x", "handler_y"}}))
+		layer := NewLayer("", constructor)
+		assert.Nil(t, layer.LoadConfigFromMemory([]byte(conf)))
+		return layer, handlers
+	}
+	conf := "name: overrides_layer\ndivider: test_outputs_divider\nhandlers:\n  - handler_x\n  - handler_y\noverrides:\n  attribute: user_id\n  ids:\n    \"42\": handler_y\n"
+
+	t.Run("Input=forced", func(t *testing.T) {
+		layer, handlers := newLayer(t, conf)
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		ctx.EnableTrace()
+		ctx.SetAttribute("user_id", 42)
+		assert.True(t, layer.Handle(ctx))
+		assert.Equal(t, int32(0), handlers["handler_x"].calls)
+		assert.Equal(t, int32(1), handlers["handler_y"].calls)
+		assert.Equal(t, []ghgroupscontext.Exposure{{Layer: "overrides_layer", Handler: "handler_y", Forced: true, Reason: ReasonOverride}}, ctx.Exposures())
+		forced, ok := ctx.TraceRoot().Get("forced")
+		assert.True(t, ok)
+		assert.Equal(t, ReasonOverride, forced)
+	})
+
+	t.Run("Input=not_forced", func(t *testing.T) {
+		layer, handlers := newLayer(t, conf)
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		ctx.SetAttribute("user_id", 7)
+		assert.True(t, layer.Handle(ctx))
+		assert.Equal(t, int32(1), handlers["handler_x"].calls)
+		assert.Equal(t, []ghgroupscontext.Exposure{{Layer: "overrides_layer", Handler: "handler_x", Reason: ReasonDivider}}, ctx.Exposures())
+	})
+
+	t.Run("Input=update", func(t *testing.T) {
+		layer, handlers := newLayer(t, conf)
+		assert.Nil(t, layer.SetOverrides(OverridesConf{Attribute: "device_id", IDs: map[string]string{"d1": "handler_y"}}))
+		assert.Equal(t, OverridesConf{Attribute: "device_id", IDs: map[string]string{"d1": "handler_y"}}, layer.Overrides())
+
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		ctx.SetAttribute("user_id", 42)
+		ctx.SetAttribute("device_id", "d1")
+		assert.True(t, layer.Handle(ctx))
+		assert.Equal(t, int32(1), handlers["handler_y"].calls)
+
+		assert.Nil(t, layer.SetOverrides(OverridesConf{}))
+		assert.True(t, layer.Handle(ctx))
+		assert.Equal(t, int32(1), handlers["handler_x"].calls)
+	})
+
+	t.Run("Input=invalid", func(t *testing.T) {
+		layer, _ := newLayer(t, conf)
+		err := layer.SetOverrides(OverridesConf{Attribute: "user_id", IDs: map[string]string{"42": "handler_z"}})
+		assert.ErrorContains(t, err, "layer overrides_layer override handler_z for 42 is not in handlers")
+		err = layer.SetOverrides(OverridesConf{IDs: map[string]string{"42": "handler_x"}})
+		assert.ErrorContains(t, err, "layer overrides_layer overrides must have attribute")
+		assert.Equal(t, "handler_y", layer.Overrides().IDs["42"])
+	})
+}
+
+func TestDebugSelection(t *testing.T) {
+	constructor := utils.BuildConstructor("")
+	handlers := make(map[string]*testRecordHandler)
+	for _, name := range []string{"handler_x", "handler_y"} {
+		handlers[name] = &testRecordHandler{name: name, status: true}
+		assert.Nil(t, constructor.RegisterHandler(name, handlers[name]))
+	}
+	assert.Nil(t, constructor.RegisterDivider("test_outputs_divider", &testOutputsDivider{outputs: []string{"handler_x", "handler_y"}}))
+	layer := NewLayer("", constructor)
+	assert.Nil(t, layer.LoadConfigFromMemory([]byte("name: debug_layer\ndivider: test_outputs_divider\nhandlers:\n  - handler_x\n  - handler_y\n")))
+
+	ctx := ghgroupscontext.NewGhGroupsContext(nil)
+	ctx.SetDebugSelection("debug_layer", "handler_y")
+	assert.True(t, layer.Handle(ctx))
+	assert.Equal(t, int32(1), handlers["handler_x"].calls)
+	assert.Equal(t, int32(0), handlers["handler_y"].calls)
+
+	constructor.EnableDebugOverrides(true)
+	ctx = ghgroupscontext.NewGhGroupsContext(nil)
+	ctx.SetDebugSelection("debug_layer", "handler_y")
+	assert.True(t, layer.Handle(ctx))
+	assert.Equal(t, int32(1), handlers["handler_y"].calls)
+	assert.Equal(t, []ghgroupscontext.Exposure{{Layer: "debug_layer", Handler: "handler_y", Forced: true, Reason: ReasonDebug}}, ctx.Exposures())
+}
+
+func TestControl(t *testing.T) {
+	constructor := utils.BuildConstructor("")
+	for _, name := range []string{"handler_x", "handler_y"} {
+		assert.Nil(t, constructor.RegisterHandler(name, &testRecordHandler{name: name, status: true}))
+	}
+	assert.Nil(t, constructor.RegisterDivider("test_outputs_divider", &testOutputsDivider{outputs: []string{"handler_x", "handler_y"}}))
+
+	layer := NewLayer("", constructor)
+	assert.Nil(t, layer.LoadConfigFromMemory([]byte("name: control_layer\ndivider: test_outputs_divider\ncontrol: handler_x\nhandlers:\n  - handler_x\n  - handler_y\n")))
+	assert.Equal(t, "handler_x", layer.Control())
+
+	layer = NewLayer("", constructor)
+	err := layer.LoadConfigFromMemory([]byte("name: control_layer\ndivider: test_outputs_divider\ncontrol: handler_z\nhandlers:\n  - handler_x\n  - handler_y\n"))
+	assert.ErrorContains(t, err, "layer control_layer control handler_z is not in handlers")
+}
+
+type testParamsHandler struct {
+	frame.HandlerBaseInterface
+	topK int
+}
+
+func (h *testParamsHandler) Name() string {
+	return "handler_topk"
+}
+
+func (h *testParamsHandler) Handle(context *ghgroupscontext.GhGroupsContext) bool {
+	h.topK, _ = context.ParamInt("top_k")
+	return true
+}
+
+func TestParams(t *testing.T) {
+	newLayer := func(t *testing.T, selected string, conf string) (*Layer, error) {
+		constructor := utils.BuildConstructor("")
+		assert.Nil(t, constructor.RegisterHandler("handler_topk", &testParamsHandler{}))
+		assert.Nil(t, constructor.RegisterDivider("test_outputs_divider", &testOutputsDivider{outputs: []string{selected}}))
+		layer := NewLayer("", constructor)
+		return layer, layer.LoadConfigFromMemory([]byte(conf))
+	}
+	conf := "name: params_layer\ndivider: test_outputs_divider\ndefault: bid_low\nhandlers:\n  - handler_topk\nparams:\n  bid_low:\n    bid_multiplier: 0.8\n  bid_high:\n    bid_multiplier: 1.2\n  handler_topk:\n    top_k: 20\n"
+
+	t.Run("Input=params_only", func(t *testing.T) {
+		layer, err := newLayer(t, "bid_high", conf)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"layer params_layer handler handler_topk can never be selected by divider test_outputs_divider"}, layer.Warnings())
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		assert.True(t, layer.Handle(ctx))
+		multiplier, ok := ctx.ParamFloat("bid_multiplier")
+		assert.True(t, ok)
+		assert.Equal(t, 1.2, multiplier)
+		assert.Equal(t, []ghgroupscontext.Exposure{{Layer: "params_layer", Handler: "bid_high", Reason: ReasonDivider}}, ctx.Exposures())
+	})
+
+	t.Run("Input=params_with_handler", func(t *testing.T) {
+		layer, err := newLayer(t, "handler_topk", conf)
+		assert.Nil(t, err)
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		ctx.EnableTrace()
+		assert.True(t, layer.Handle(ctx))
+		assert.Equal(t, 20, layer.handlers["handler_topk"].(*paramsHandler).handler.(*testParamsHandler).topK)
+		variant := ctx.TraceRoot().Find("handler_topk")
+		assert.Len(t, variant.Children, 1)
+		assert.Equal(t, "handler_topk", variant.Children[0].Name)
+	})
+
+	t.Run("Input=output_without_variant", func(t *testing.T) {
+		_, err := newLayer(t, "bid_mid", conf)
+		assert.ErrorContains(t, err, "layer params_layer divider test_outputs_divider may select bid_mid which is not in handlers")
+	})
+
+	t.Run("Input=empty_params", func(t *testing.T) {
+		_, err := newLayer(t, "bid_low", "name: params_layer\ndivider: test_outputs_divider\nparams:\n  bid_low: {}\n")
+		assert.ErrorContains(t, err, "layer params_layer params bid_low is empty")
+	})
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func TestSchedules(t *testing.T) {
+	newLayer := func(t *testing.T, selected string, schedules string) (*Layer, error) {
+		constructor := utils.BuildConstructor("")
+		for _, name := range []string{"handler_x", "handler_y"} {
+			assert.Nil(t, constructor.RegisterHandler(name, &testRecordHandler{name: name, status: true}))
+		}
+		assert.Nil(t, constructor.RegisterDivider("test_outputs_divider", &testOutputsDivider{outputs: []string{selected}}))
+		layer := NewLayer("", constructor)
+		conf := "name: schedule_layer\ndivider: test_outputs_divider\ndefault: handler_x\nhandlers:\n  - handler_x\n  - handler_y\nschedules:\n" + schedules
+		return layer, layer.LoadConfigFromMemory([]byte(conf))
+	}
+	handle := func(layer *Layer, clock *testClock, now string) ghgroupscontext.Exposure {
+		clock.now, _ = time.Parse(time.RFC3339, now)
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		layer.Handle(ctx)
+		return ctx.Exposures()[0]
+	}
+
+	t.Run("Input=window", func(t *testing.T) {
+		layer, err := newLayer(t, "handler_y", "  handler_y:\n    start_at: 2026-03-01T00:00:00\n    end_at: 2026-04-01T00:00:00\n    time_zone: Asia/Shanghai\n    daily:\n      - from: \"22:00\"\n        to: \"02:00\"\n")
+		assert.Nil(t, err)
+		clock := &testClock{}
+		layer.SetClock(clock)
+
+		assert.Equal(t, ghgroupscontext.Exposure{Layer: "schedule_layer", Handler: "handler_x", Reason: ReasonSchedule}, handle(layer, clock, "2026-02-28T15:00:00Z"))
+		assert.Equal(t, "handler_y", handle(layer, clock, "2026-03-10T15:00:00Z").Handler)
+		assert.Equal(t, "handler_y", handle(layer, clock, "2026-03-10T17:59:00Z").Handler)
+		assert.Equal(t, "handler_x", handle(layer, clock, "2026-03-10T18:00:00Z").Handler)
+		assert.Empty(t, layer.Warnings())
+
+		assert.Equal(t, "handler_x", handle(layer, clock, "2026-03-31T16:00:00Z").Handler)
+		assert.Equal(t, "handler_x", handle(layer, clock, "2026-04-02T15:00:00Z").Handler)
+		assert.Equal(t, []string{"layer schedule_layer handler handler_y expired at 2026-04-01T00:00:00+08:00, fall back to default handler_x"}, layer.Warnings())
+	})
+
+	t.Run("Input=forced", func(t *testing.T) {
+		layer, err := newLayer(t, "handler_x", "  handler_y:\n    end_at: 2026-01-01T00:00:00Z\n")
+		assert.Nil(t, err)
+		assert.Nil(t, layer.SetOverrides(OverridesConf{Attribute: "user_id", IDs: map[string]string{"qa": "handler_y"}}))
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		ctx.SetAttribute("user_id", "qa")
+		assert.True(t, layer.Handle(ctx))
+		assert.Equal(t, "handler_y", ctx.Exposures()[0].Handler)
+	})
+
+	invalidCases := map[string]string{
+		"Input=default":       "  handler_x:\n    end_at: 2026-01-01T00:00:00Z\n",
+		"Input=not_handler":   "  handler_z:\n    end_at: 2026-01-01T00:00:00Z\n",
+		"Input=reversed":      "  handler_y:\n    start_at: 2026-02-01T00:00:00Z\n    end_at: 2026-01-01T00:00:00Z\n",
+		"Input=bad_time":      "  handler_y:\n    start_at: tomorrow\n",
+		"Input=bad_daily":     "  handler_y:\n    daily:\n      - from: \"9am\"\n        to: \"18:00\"\n",
+		"Input=bad_time_zone": "  handler_y:\n    time_zone: Mars/Olympus\n",
+	}
+	expected := map[string]string{
+		"Input=default":       "layer schedule_layer default handler_x can not have schedule",
+		"Input=not_handler":   "layer schedule_layer schedule handler_z is not in handlers",
+		"Input=reversed":      "start_at 2026-02-01T00:00:00Z must be before end_at 2026-01-01T00:00:00Z",
+		"Input=bad_time":      "time tomorrow must be RFC3339",
+		"Input=bad_daily":     "daily time 9am must be HH:MM",
+		"Input=bad_time_zone": "unknown time zone Mars/Olympus",
+	}
+	for name, schedules := range invalidCases {
+		schedules, expectedError := schedules, expected[name]
+		t.Run(name, func(t *testing.T) {
+			_, err := newLayer(t, "handler_x", schedules)
+			assert.ErrorContains(t, err, expectedError)
+		})
+	}
+}
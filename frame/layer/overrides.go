@@ -0,0 +1,70 @@
+package layer
+
+import (
+	"fmt"
+	"ghgroups/frame/bucket"
+
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+)
+
+// 白名单：attribute属性值在ids中的请求，跳过divider直接使用指定的handler
+// 白名单可以在运行时通过SetOverrides整体替换，不需要重新构建Layer
+
+type OverridesConf struct {
+	Attribute string            `yaml:"attribute"`
+	IDs       map[string]string `yaml:"ids"`
+}
+
+// SetOverrides 校验并原子替换白名单，ids为空时清除白名单
+func (l *Layer) SetOverrides(overridesConf OverridesConf) error {
+	if err := l.checkOverrides(overridesConf); err != nil {
+		return err
+	}
+	ids := make(map[string]string, len(overridesConf.IDs))
+	for id, handlerName := range overridesConf.IDs {
+		ids[id] = handlerName
+	}
+	l.overrides.Store(&OverridesConf{Attribute: overridesConf.Attribute, IDs: ids})
+	return nil
+}
+
+// Overrides 返回当前生效的白名单的拷贝
+func (l *Layer) Overrides() OverridesConf {
+	overridesConf := l.overrides.Load()
+	if overridesConf == nil {
+		return OverridesConf{}
+	}
+	ids := make(map[string]string, len(overridesConf.IDs))
+	for id, handlerName := range overridesConf.IDs {
+		ids[id] = handlerName
+	}
+	return OverridesConf{Attribute: overridesConf.Attribute, IDs: ids}
+}
+
+func (l *Layer) checkOverrides(overridesConf OverridesConf) error {
+	if len(overridesConf.IDs) == 0 {
+		return nil
+	}
+	if overridesConf.Attribute == "" {
+		return fmt.Errorf("layer %s overrides must have attribute", l.conf.Name)
+	}
+	for id, handlerName := range overridesConf.IDs {
+		if _, ok := l.handlers[handlerName]; !ok {
+			return fmt.Errorf("layer %s override %s for %s is not in handlers", l.conf.Name, handlerName, id)
+		}
+	}
+	return nil
+}
+
+func (l *Layer) overrideSelection(ctx *ghgroupscontext.GhGroupsContext) (string, bool) {
+	overridesConf := l.overrides.Load()
+	if overridesConf == nil || len(overridesConf.IDs) == 0 {
+		return "", false
+	}
+	id, ok := bucket.UnitID(ctx, overridesConf.Attribute)
+	if !ok {
+		return "", false
+	}
+	handlerName, ok := overridesConf.IDs[id]
+	return handlerName, ok
+}
@@ -0,0 +1,66 @@
+package layer
+
+import (
+	"ghgroups/frame"
+	"sort"
+
+	debughelper "ghgroups/frame/debug_helper"
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+)
+
+// 参数实验：params中每个分支是一组参数，被选中时参数写入GhGroupsContext，下游handler通过ParamInt等方法读取
+// 分支名称与handlers中的handler相同时，先写入参数再执行该handler；否则分支只写入参数，直接返回成功
+// 参数分支与handler一样参与divider、default、overrides、control等所有逻辑
+
+type paramsHandler struct {
+	frame.HandlerBaseInterface
+	name    string
+	params  map[string]any
+	handler frame.HandlerBaseInterface
+}
+
+func (p *paramsHandler) Name() string {
+	return p.name
+}
+
+func (p *paramsHandler) Handle(ctx *ghgroupscontext.GhGroupsContext) bool {
+	ctx.SetParams(p.params)
+	ctx.Trace("params", p.params)
+	if p.handler == nil {
+		return true
+	}
+	return debughelper.HandleWithShowDuration(p.handler, p.handler.Name(), ctx)
+}
+
+func (l *Layer) initParams() error {
+	for variantName, params := range l.conf.Params {
+		copied := make(map[string]any, len(params))
+		for key, value := range params {
+			copied[key] = value
+		}
+		l.handlers[variantName] = &paramsHandler{name: variantName, params: copied, handler: l.handlers[variantName]}
+	}
+	return nil
+}
+
+// variantsName 返回handlers和params中的所有分支名称，handlers中的在前并保持配置顺序
+func (l *Layer) variantsName() []string {
+	names := append([]string(nil), l.conf.Handlers...)
+	paramsName := make([]string, 0, len(l.conf.Params))
+	for variantName := range l.conf.Params {
+		if !contains(l.conf.Handlers, variantName) {
+			paramsName = append(paramsName, variantName)
+		}
+	}
+	sort.Strings(paramsName)
+	return append(names, paramsName...)
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
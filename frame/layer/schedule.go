@@ -0,0 +1,172 @@
+package layer
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+)
+
+// 分支的生效时间：schedules中按分支名称配置start_at、end_at以及每天的生效时段daily
+// 时间使用RFC3339格式，不带时区时按time_zone解析，daily的时段也按time_zone计算，默认UTC
+// divider选中了不在生效时间内的分支时改用default，已经过期的分支第一次被选中时给出警告
+// overrides、holdout、调试覆盖等强制选择不受schedules限制
+
+type ScheduleConf struct {
+	StartAt  string            `yaml:"start_at"`
+	EndAt    string            `yaml:"end_at"`
+	TimeZone string            `yaml:"time_zone"`
+	Daily    []DailyWindowConf `yaml:"daily"`
+}
+
+// DailyWindowConf 是每天的生效时段[from, to)，格式为HH:MM，to小于from时表示跨越零点
+type DailyWindowConf struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// Clock 是Layer判断分支生效时间时使用的时间来源，测试中可以注入固定的时间
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SetClock 替换时间来源，需要在Layer开始服务前调用
+func (l *Layer) SetClock(clock Clock) {
+	l.clock = clock
+}
+
+type schedule struct {
+	startAt  time.Time
+	endAt    time.Time
+	location *time.Location
+	daily    [][2]int
+	expired  uint32
+}
+
+func (l *Layer) initSchedules() error {
+	if len(l.conf.Schedules) == 0 {
+		return nil
+	}
+	// 配置已经由LayerConf.Check校验过
+	l.schedules = make(map[string]*schedule, len(l.conf.Schedules))
+	for variantName, scheduleConf := range l.conf.Schedules {
+		s, err := newSchedule(scheduleConf)
+		if err != nil {
+			return fmt.Errorf("layer %s schedule %s: %v", l.conf.Name, variantName, err)
+		}
+		l.schedules[variantName] = s
+	}
+	return nil
+}
+
+func newSchedule(scheduleConf ScheduleConf) (*schedule, error) {
+	location := time.UTC
+	if scheduleConf.TimeZone != "" {
+		var err error
+		location, err = time.LoadLocation(scheduleConf.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s := &schedule{location: location}
+	var err error
+	if s.startAt, err = parseScheduleTime(scheduleConf.StartAt, location); err != nil {
+		return nil, err
+	}
+	if s.endAt, err = parseScheduleTime(scheduleConf.EndAt, location); err != nil {
+		return nil, err
+	}
+	if !s.startAt.IsZero() && !s.endAt.IsZero() && !s.startAt.Before(s.endAt) {
+		return nil, fmt.Errorf("start_at %s must be before end_at %s", scheduleConf.StartAt, scheduleConf.EndAt)
+	}
+	for _, windowConf := range scheduleConf.Daily {
+		from, err := parseClock(windowConf.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseClock(windowConf.To)
+		if err != nil {
+			return nil, err
+		}
+		if from == to {
+			return nil, fmt.Errorf("daily window %s-%s is empty", windowConf.From, windowConf.To)
+		}
+		s.daily = append(s.daily, [2]int{from, to})
+	}
+	return s, nil
+}
+
+func parseScheduleTime(value string, location *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02T15:04:05", value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("time %s must be RFC3339", value)
+	}
+	return t, nil
+}
+
+// parseClock 把HH:MM解析为当天的分钟数
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("daily time %s must be HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (s *schedule) active(now time.Time) bool {
+	if !s.startAt.IsZero() && now.Before(s.startAt) {
+		return false
+	}
+	if s.expiredAt(now) {
+		return false
+	}
+	if len(s.daily) == 0 {
+		return true
+	}
+	local := now.In(s.location)
+	minute := local.Hour()*60 + local.Minute()
+	for _, window := range s.daily {
+		from, to := window[0], window[1]
+		if from < to && minute >= from && minute < to {
+			return true
+		}
+		if from > to && (minute >= from || minute < to) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *schedule) expiredAt(now time.Time) bool {
+	return !s.endAt.IsZero() && !now.Before(s.endAt)
+}
+
+// scheduled 检查divider选中的分支是否在生效时间内，不在时返回default
+func (l *Layer) scheduled(ctx *ghgroupscontext.GhGroupsContext, handlerName string) (string, string) {
+	s, ok := l.schedules[handlerName]
+	if !ok {
+		return handlerName, ReasonDivider
+	}
+	now := l.clock.Now()
+	if s.active(now) {
+		return handlerName, ReasonDivider
+	}
+	if s.expiredAt(now) && atomic.CompareAndSwapUint32(&s.expired, 0, 1) {
+		l.warn(fmt.Sprintf("layer %s handler %s expired at %s, fall back to default %s", l.conf.Name, handlerName, s.endAt.Format(time.RFC3339), l.conf.Default))
+	}
+	ctx.Trace("inactive", handlerName)
+	return l.conf.Default, ReasonSchedule
+}
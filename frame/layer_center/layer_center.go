@@ -3,19 +3,177 @@ package layercenter
 import (
 	"fmt"
 	"ghgroups/frame"
+	"ghgroups/frame/bucket"
 	debughelper "ghgroups/frame/debug_helper"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"os"
+	"sync"
 
 	"gopkg.in/yaml.v2"
 )
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
+// 配置了domains时，LayerCenter按重叠实验的方式组织Layer
+// 流量按unit_id属性哈希分桶，每个请求只落入一个domain，只执行该domain中的Layer
+// 同一domain中的Layer互相正交，它们的divider需要使用各自的salt分流（如BucketDivider）
+// launch_layers对所有流量生效，并在domain中的Layer之前执行
+// holdout按unit_id哈希选出percentage%的稳定流量，这些请求在本LayerCenter的所有Layer中都直接使用对照组
+// 对照组优先取holdout.controls中的配置，没有配置时使用Layer自身的control
+// 配置了holdout时每个请求都会以LayerCenter名称记录一条Exposure，handler为holdout或experiment
+// mode为parallel时所有选中的Layer并发执行，全部完成后任意一个失败即失败，与AsyncHandlerGroup一致
+
 type LayerCenterConf struct {
-	Type   string   `yaml:"type"`
-	Name   string   `yaml:"name"`
-	Layers []string `yaml:"layers"`
+	Type         string       `yaml:"type"`
+	Name         string       `yaml:"name"`
+	Layers       []string     `yaml:"layers"`
+	UnitID       string       `yaml:"unit_id"`
+	Salt         string       `yaml:"salt"`
+	Buckets      uint32       `yaml:"buckets"`
+	Domains      []DomainConf `yaml:"domains"`
+	LaunchLayers []string     `yaml:"launch_layers"`
+	Mode         string       `yaml:"mode"`
+	Holdout      *HoldoutConf `yaml:"holdout"`
+}
+
+type HoldoutConf struct {
+	UnitID     string            `yaml:"unit_id"`
+	Salt       string            `yaml:"salt"`
+	Percentage float64           `yaml:"percentage"`
+	Controls   map[string]string `yaml:"controls"`
+}
+
+// holdout分桶的精度为0.01%
+const holdoutBuckets = 10000
+
+// 记录holdout成员关系的Exposure中的handler名称
+const (
+	HoldoutGroup    = "holdout"
+	ExperimentGroup = "experiment"
+)
+
+const (
+	ModeSequential = "sequential"
+	ModeParallel   = "parallel"
+)
+
+type DomainConf struct {
+	Name   string       `yaml:"name"`
+	Range  bucket.Range `yaml:"range"`
+	Layers []string     `yaml:"layers"`
+}
+
+func init() {
+	frame.RegisterConfParser("LayerCenter", func(configure []byte) error {
+		_, err := ParseConf(configure)
+		return err
+	})
+}
+
+// ParseConf 解析并校验LayerCenter的配置，不会构建其中的Layer，Constructor在创建LayerCenter之前以同样的方式校验配置
+func ParseConf(configure []byte) (LayerCenterConf, error) {
+	var layerCenterConf LayerCenterConf
+	if err := yaml.Unmarshal(configure, &layerCenterConf); err != nil {
+		return layerCenterConf, err
+	}
+	return layerCenterConf, layerCenterConf.Check()
+}
+
+// Check 校验不依赖Layer实例的配置：mode、domains的结构和桶区间、holdout的参数
+func (c *LayerCenterConf) Check() error {
+	switch c.Mode {
+	case "", ModeSequential, ModeParallel:
+	default:
+		return fmt.Errorf("layer center %s mode %s is invalid, must be sequential or parallel", c.Name, c.Mode)
+	}
+	if err := c.checkDomains(); err != nil {
+		return err
+	}
+	return c.checkHoldout()
+}
+
+func (c *LayerCenterConf) checkDomains() error {
+	if len(c.Domains) == 0 {
+		if len(c.LaunchLayers) > 0 {
+			return fmt.Errorf("layer center %s has launch_layers but no domains", c.Name)
+		}
+		return nil
+	}
+	if len(c.Layers) > 0 {
+		return fmt.Errorf("layer center %s can not have both layers and domains, use launch_layers for layers of all traffic", c.Name)
+	}
+	if c.UnitID == "" {
+		return fmt.Errorf("layer center %s has domains but no unit_id", c.Name)
+	}
+
+	// 同一个Layer只能出现在一个domain或者launch_layers中，否则流量会重叠
+	owners := make(map[string]string)
+	claim := func(layerName string, owner string) error {
+		if previous, ok := owners[layerName]; ok {
+			return fmt.Errorf("layer center %s layer %s is in both %s and %s", c.Name, layerName, previous, owner)
+		}
+		owners[layerName] = owner
+		return nil
+	}
+
+	domainsName := make(map[string]struct{})
+	namedRanges := make([]bucket.NamedRange, 0, len(c.Domains))
+	for _, domainConf := range c.Domains {
+		if domainConf.Name == "" {
+			return fmt.Errorf("layer center %s has domain without name", c.Name)
+		}
+		if _, ok := domainsName[domainConf.Name]; ok {
+			return fmt.Errorf("layer center %s domain %s already exists", c.Name, domainConf.Name)
+		}
+		domainsName[domainConf.Name] = struct{}{}
+		for _, layerName := range domainConf.Layers {
+			if err := claim(layerName, "domain "+domainConf.Name); err != nil {
+				return err
+			}
+		}
+		namedRanges = append(namedRanges, bucket.NamedRange{Name: "domain " + domainConf.Name, Range: domainConf.Range})
+	}
+	for _, layerName := range c.LaunchLayers {
+		if err := claim(layerName, "launch_layers"); err != nil {
+			return err
+		}
+	}
+	buckets := c.Buckets
+	if buckets == 0 {
+		buckets = bucket.DefaultBuckets
+	}
+	if err := bucket.CheckOverlap(namedRanges, buckets); err != nil {
+		return fmt.Errorf("layer center %s: %v", c.Name, err)
+	}
+	return nil
+}
+
+func (c *LayerCenterConf) checkHoldout() error {
+	holdout := c.Holdout
+	if holdout == nil {
+		return nil
+	}
+	if holdout.UnitID == "" {
+		return fmt.Errorf("layer center %s holdout must have unit_id", c.Name)
+	}
+	if holdout.Percentage <= 0 || holdout.Percentage >= 100 {
+		return fmt.Errorf("layer center %s holdout percentage %v must be in (0, 100)", c.Name, holdout.Percentage)
+	}
+	layersName := make(map[string]struct{})
+	for _, layerName := range append(append([]string(nil), c.Layers...), c.LaunchLayers...) {
+		layersName[layerName] = struct{}{}
+	}
+	for _, domainConf := range c.Domains {
+		for _, layerName := range domainConf.Layers {
+			layersName[layerName] = struct{}{}
+		}
+	}
+	for layerName := range holdout.Controls {
+		if _, ok := layersName[layerName]; !ok {
+			return fmt.Errorf("layer center %s holdout has control for unknown layer %s", c.Name, layerName)
+		}
+	}
+	return nil
 }
 
 type LayerCenter struct {
@@ -24,6 +182,17 @@ type LayerCenter struct {
 	constructorInterface frame.ConstructorInterface
 	conf                 LayerCenterConf
 	layers               []frame.LayerBaseInterface
+	layersName           []string
+	launchLayers         []frame.LayerBaseInterface
+	domains              []domain
+	holdoutControls      map[string]string
+}
+
+type domain struct {
+	name       string
+	r          bucket.Range
+	layers     []frame.LayerBaseInterface
+	layersName []string
 }
 
 func NewLayerCenter(constructorInterface frame.ConstructorInterface) *LayerCenter {
@@ -37,22 +206,144 @@ func NewLayerCenter(constructorInterface frame.ConstructorInterface) *LayerCente
 // LayerCenterInterface
 
 func (l *LayerCenter) init() error {
-	for _, layerName := range l.conf.Layers {
+	// 先校验配置，配置有误时不会构建任何Layer
+	err := l.conf.Check()
+	if err != nil {
+		return err
+	}
+
+	layers, err := l.initLayers(l.conf.Layers)
+	if err != nil {
+		return err
+	}
+	l.layers = append(l.layers, layers...)
+	l.layersName = append(l.layersName, l.conf.Layers...)
+
+	err = l.initDomains()
+	if err != nil {
+		return err
+	}
+	return l.initHoldout()
+}
+
+func (l *LayerCenter) initHoldout() error {
+	holdout := l.conf.Holdout
+	if holdout == nil {
+		return nil
+	}
+	if holdout.Salt == "" {
+		holdout.Salt = l.conf.Name + ".holdout"
+	}
+
+	type controlLayer interface {
+		Control() string
+		HasHandler(name string) bool
+	}
+	layers := append(append([]frame.LayerBaseInterface(nil), l.layers...), l.launchLayers...)
+	for _, domain := range l.domains {
+		layers = append(layers, domain.layers...)
+	}
+	controls := make(map[string]string, len(layers))
+	for _, layer := range layers {
+		controlLayer, _ := layer.(controlLayer)
+		control, ok := holdout.Controls[layer.Name()]
+		if !ok && controlLayer != nil {
+			control = controlLayer.Control()
+		}
+		if control == "" {
+			return fmt.Errorf("layer center %s holdout has no control for layer %s", l.conf.Name, layer.Name())
+		}
+		if controlLayer != nil && !controlLayer.HasHandler(control) {
+			return fmt.Errorf("layer center %s holdout control %s is not in handlers of layer %s", l.conf.Name, control, layer.Name())
+		}
+		controls[layer.Name()] = control
+	}
+	l.holdoutControls = controls
+	return nil
+}
+
+// checkHoldout 判断请求是否落入holdout并记录成员关系，没有unit_id的请求不进入holdout
+func (l *LayerCenter) checkHoldout(ctx *ghgroupscontext.GhGroupsContext) {
+	if l.conf.Holdout == nil {
+		return
+	}
+	group := ExperimentGroup
+	if unitID, ok := bucket.UnitID(ctx, l.conf.Holdout.UnitID); ok {
+		index := bucket.Bucket(unitID, l.conf.Holdout.Salt, holdoutBuckets)
+		if float64(index) < l.conf.Holdout.Percentage*holdoutBuckets/100 {
+			group = HoldoutGroup
+			ctx.SetHoldout(l.holdoutControls)
+		}
+	}
+	ctx.Trace("holdout", group == HoldoutGroup)
+	ctx.Expose(ghgroupscontext.Exposure{Layer: l.conf.Name, Handler: group, Reason: HoldoutGroup})
+}
+
+func (l *LayerCenter) initLayers(layersName []string) ([]frame.LayerBaseInterface, error) {
+	layers := make([]frame.LayerBaseInterface, 0, len(layersName))
+	for _, layerName := range layersName {
 		if err := l.constructorInterface.CreateConcrete(layerName); err != nil {
-			return err
+			return nil, err
 		}
 
 		if someInterface, err := l.constructorInterface.GetConcrete(layerName); err != nil {
-			return err
+			return nil, err
 		} else {
 			if layerBaseInterface, ok := someInterface.(frame.LayerBaseInterface); !ok {
-				return fmt.Errorf("layer %s is not frame.LayerBaseInterface", layerName)
+				return nil, fmt.Errorf("layer %s is not frame.LayerBaseInterface", layerName)
 			} else {
-				l.layers = append(l.layers, layerBaseInterface)
+				layers = append(layers, layerBaseInterface)
 			}
 		}
 	}
+	return layers, nil
+}
+
+func (l *LayerCenter) initDomains() error {
+	if len(l.conf.Domains) == 0 {
+		return nil
+	}
+	if l.conf.Salt == "" {
+		l.conf.Salt = l.conf.Name
+	}
+	if l.conf.Buckets == 0 {
+		l.conf.Buckets = bucket.DefaultBuckets
+	}
+
+	launchLayers, err := l.initLayers(l.conf.LaunchLayers)
+	if err != nil {
+		return err
+	}
+	domains := make([]domain, 0, len(l.conf.Domains))
+	for _, domainConf := range l.conf.Domains {
+		layers, err := l.initLayers(domainConf.Layers)
+		if err != nil {
+			return err
+		}
+		if err := l.checkSalts(domainConf.Name, append(append([]frame.LayerBaseInterface{}, launchLayers...), layers...)); err != nil {
+			return err
+		}
+		domains = append(domains, domain{name: domainConf.Name, r: domainConf.Range, layers: layers, layersName: domainConf.Layers})
+	}
+	l.launchLayers = launchLayers
+	l.domains = domains
+	return nil
+}
 
+// checkSalts 同一domain中的Layer（包括launch layers）使用相同的salt时分流结果完全相关，不再正交
+func (l *LayerCenter) checkSalts(domainName string, layers []frame.LayerBaseInterface) error {
+	salts := make(map[string]string, len(layers))
+	for _, layer := range layers {
+		saltInterface, ok := layer.(frame.SaltInterface)
+		if !ok || saltInterface.Salt() == "" {
+			continue
+		}
+		salt := saltInterface.Salt()
+		if other, ok := salts[salt]; ok {
+			return fmt.Errorf("layer center %s layers %s and %s in domain %s use the same salt %s", l.conf.Name, other, layer.Name(), domainName, salt)
+		}
+		salts[salt] = layer.Name()
+	}
 	return nil
 }
 
@@ -63,19 +354,118 @@ func (l *LayerCenter) Name() string {
 
 func (l *LayerCenter) Add(layerInterface frame.LayerWithBuilderInterface) {
 	l.layers = append(l.layers, layerInterface)
+	l.layersName = append(l.layersName, layerInterface.Name())
+}
+
+// Rebind 把引用名称为name的layer替换为concrete，供Constructor.Override使用，不能与Handle并发调用
+// 按配置中引用的名称查找，之前Override的组件Name与name不同时也能再次替换
+func (l *LayerCenter) Rebind(name string, concrete any) (bool, error) {
+	replace := func(layersName []string, layers []frame.LayerBaseInterface) (bool, error) {
+		rebound := false
+		for i, layerName := range layersName {
+			if layerName != name {
+				continue
+			}
+			layerBaseInterface, ok := concrete.(frame.LayerBaseInterface)
+			if !ok {
+				return false, fmt.Errorf("layer %s is not frame.LayerBaseInterface", name)
+			}
+			layers[i] = layerBaseInterface
+			rebound = true
+		}
+		return rebound, nil
+	}
+
+	rebound, err := replace(l.layersName, l.layers)
+	if err != nil {
+		return false, err
+	}
+	launchRebound, err := replace(l.conf.LaunchLayers, l.launchLayers)
+	if err != nil {
+		return false, err
+	}
+	rebound = rebound || launchRebound
+	for _, domain := range l.domains {
+		domainRebound, err := replace(domain.layersName, domain.layers)
+		if err != nil {
+			return false, err
+		}
+		rebound = rebound || domainRebound
+	}
+	return rebound, nil
 }
 
 func (l *LayerCenter) Handle(ctx *ghgroupscontext.GhGroupsContext) bool {
-	for _, layer := range l.layers {
-		if debughelper.HandleWithShowDuration(layer, layer.Name(), ctx) {
-			continue
-		} else {
-			return false
+	l.checkHoldout(ctx)
+	if l.conf.Mode == ModeParallel {
+		return l.handleParallel(ctx)
+	}
+	for _, layers := range l.selectLayers(ctx) {
+		for _, layer := range layers {
+			if debughelper.SkipDisabled(l.constructorInterface, layer.Name(), ctx) {
+				continue
+			}
+			if debughelper.HandleWithShowDuration(layer, layer.Name(), ctx) {
+				continue
+			} else {
+				return false
+			}
 		}
 	}
 	return true
 }
 
+func (l *LayerCenter) handleParallel(ctx *ghgroupscontext.GhGroupsContext) bool {
+	wg := sync.WaitGroup{}
+	var layers []frame.LayerBaseInterface
+	for _, selected := range l.selectLayers(ctx) {
+		layers = append(layers, selected...)
+	}
+	checkChan := make(chan bool, len(layers))
+	for _, layer := range layers {
+		if debughelper.SkipDisabled(l.constructorInterface, layer.Name(), ctx) {
+			continue
+		}
+		wg.Add(1)
+		go func(layer frame.LayerBaseInterface) {
+			status := debughelper.HandleWithShowDuration(layer, layer.Name(), ctx)
+			checkChan <- status
+			wg.Done()
+		}(layer)
+	}
+	wg.Wait()
+	for {
+		select {
+		case status := <-checkChan:
+			if !status {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+}
+
+func (l *LayerCenter) selectLayers(ctx *ghgroupscontext.GhGroupsContext) [][]frame.LayerBaseInterface {
+	if len(l.domains) == 0 {
+		return [][]frame.LayerBaseInterface{l.layers}
+	}
+	selected := [][]frame.LayerBaseInterface{l.layers, l.launchLayers}
+	unitID, ok := bucket.UnitID(ctx, l.conf.UnitID)
+	if !ok {
+		return selected
+	}
+	index := bucket.Bucket(unitID, l.conf.Salt, l.conf.Buckets)
+	ctx.Trace("bucket", index)
+	for _, domain := range l.domains {
+		if domain.r.Contains(index) {
+			ctx.Trace("domain", domain.name)
+			return append(selected, domain.layers)
+		}
+	}
+	return selected
+}
+
 // /////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 func (l *LayerCenter) LoadConfigFromFile(confPath string) error {
 	data, err := os.ReadFile(confPath)
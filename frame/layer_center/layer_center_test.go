@@ -1,8 +1,13 @@
 package layercenter
 
 import (
+	"fmt"
+	"ghgroups/frame"
+	"ghgroups/frame/bucket"
+	bucketdivider "ghgroups/frame/bucket_divider"
 	ghgroupscontext "ghgroups/frame/ghgroups_context"
 	"ghgroups/frame/layer"
+	"ghgroups/frame/outcome"
 	"ghgroups/frame/utils"
 	"reflect"
 
@@ -14,6 +19,9 @@ import (
 	"strings"
 	"testing"
 
+	"sync/atomic"
+	"time"
+
 	"bou.ke/monkey"
 	"github.com/stretchr/testify/assert"
 )
@@ -114,8 +122,235 @@ func TestAdd(t *testing.T) {
 		called = true
 		return true
 	})
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(testLayer), "Handle")
 
 	ctx := ghgroupscontext.NewGhGroupsContext(nil)
 	assert.True(t, layerCenter.Handle(ctx))
 	assert.True(t, called)
 }
+
+func TestDomains(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data", "domains")
+	assert.Nil(t, errGetWd)
+
+	constructor := utils.BuildConstructor(testDataPath)
+	constructor.Register(reflect.TypeOf(sampledivider.SampleAutoConstructDivider{}))
+	constructor.Register(reflect.TypeOf(samplehandler.SampleAutoConstructHandler{}))
+	constructor.Register(reflect.TypeOf(layer.Layer{}))
+	constructor.Register(reflect.TypeOf(bucketdivider.BucketDivider{}))
+
+	invalidCases := map[string]string{
+		"Input=domains_overlap.yaml":     "layer center domains_overlap: domain domain_y [50, 100) overlaps with domain domain_x [0, 60)",
+		"Input=domains_layer_twice.yaml": "layer center domains_layer_twice layer layer_x1 is in both domain domain_x and domain domain_y",
+		"Input=domains_with_layers.yaml": "can not have both layers and domains",
+		"Input=domains_no_unit_id.yaml":  "has domains but no unit_id",
+		"Input=domains_same_salt.yaml":   "layer center domains_same_salt layers layer_salt_a and layer_salt_b in domain domain_x use the same salt shared",
+	}
+	for name, expected := range invalidCases {
+		t.Run(name, func(t *testing.T) {
+			testName := t.Name()
+			comma := strings.Index(testName, "=")
+			assert.Greater(t, comma, 0)
+			confName := testName[comma+1:]
+			confPath := path.Join(testDataPath, confName)
+			assert.FileExists(t, confPath)
+
+			layerCenter := NewLayerCenter(constructor)
+			err := layerCenter.LoadConfigFromFile(confPath)
+			assert.ErrorContains(t, err, expected)
+		})
+	}
+
+	t.Run("Input=domains_valid.yaml", func(t *testing.T) {
+		testName := t.Name()
+		comma := strings.Index(testName, "=")
+		assert.Greater(t, comma, 0)
+		confName := testName[comma+1:]
+		confPath := path.Join(testDataPath, confName)
+		assert.FileExists(t, confPath)
+
+		layerCenter := NewLayerCenter(constructor)
+		err := layerCenter.LoadConfigFromFile(confPath)
+		assert.Nil(t, err)
+
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		ctx.EnableTrace()
+		assert.True(t, layerCenter.Handle(ctx))
+		assert.Equal(t, []string{"layer_launch"}, childrenName(ctx.TraceRoot()))
+
+		counts := make(map[string]int)
+		for i := 0; i < 1000; i++ {
+			unitID := fmt.Sprint("user_", i)
+			ctx := ghgroupscontext.NewGhGroupsContext(nil)
+			ctx.EnableTrace()
+			ctx.SetAttribute("user_id", unitID)
+			assert.True(t, layerCenter.Handle(ctx))
+
+			domain, ok := ctx.TraceRoot().Get("domain")
+			assert.True(t, ok)
+			counts[domain.(string)]++
+			if bucket.Bucket(unitID, "domains_valid", 100) < 50 {
+				assert.Equal(t, "domain_x", domain)
+				assert.Equal(t, []string{"layer_launch", "layer_x1", "layer_x2"}, childrenName(ctx.TraceRoot()))
+			} else {
+				assert.Equal(t, "domain_y", domain)
+				assert.Equal(t, []string{"layer_launch", "layer_y1"}, childrenName(ctx.TraceRoot()))
+			}
+		}
+		assert.InDelta(t, 500, counts["domain_x"], 80)
+	})
+}
+
+func childrenName(node *ghgroupscontext.TraceNode) []string {
+	names := make([]string, 0, len(node.Children))
+	for _, child := range node.Children {
+		names = append(names, child.Name)
+	}
+	return names
+}
+
+type testSlowLayer struct {
+	frame.LayerBaseInterface
+	name   string
+	status bool
+	calls  int32
+}
+
+func (l *testSlowLayer) Name() string {
+	return l.name
+}
+
+func (l *testSlowLayer) Handle(ctx *ghgroupscontext.GhGroupsContext) bool {
+	time.Sleep(50 * time.Millisecond)
+	atomic.AddInt32(&l.calls, 1)
+	return l.status
+}
+
+func TestParallelMode(t *testing.T) {
+	newLayerCenter := func(t *testing.T, mode string, statuses map[string]bool) (*LayerCenter, map[string]*testSlowLayer) {
+		constructor := utils.BuildConstructor("")
+		layers := make(map[string]*testSlowLayer)
+		for name, status := range statuses {
+			layers[name] = &testSlowLayer{name: name, status: status}
+			assert.Nil(t, constructor.RegisterLayer(name, layers[name]))
+		}
+		layerCenter := NewLayerCenter(constructor)
+		err := layerCenter.LoadConfigFromMemory([]byte(fmt.Sprintf("name: parallel_center\nmode: %s\nlayers:\n  - creative\n  - bid_shading\n", mode)))
+		assert.Nil(t, err)
+		return layerCenter, layers
+	}
+
+	t.Run("Input=parallel", func(t *testing.T) {
+		layerCenter, layers := newLayerCenter(t, ModeParallel, map[string]bool{"creative": true, "bid_shading": true})
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		ctx.EnableTrace()
+		start := time.Now()
+		assert.True(t, layerCenter.Handle(ctx))
+		assert.Less(t, time.Since(start), 90*time.Millisecond)
+		assert.ElementsMatch(t, []string{"creative", "bid_shading"}, childrenName(ctx.TraceRoot()))
+		for _, layer := range layers {
+			assert.Equal(t, int32(1), layer.calls)
+		}
+	})
+
+	t.Run("Input=parallel_failure", func(t *testing.T) {
+		layerCenter, layers := newLayerCenter(t, ModeParallel, map[string]bool{"creative": false, "bid_shading": true})
+		assert.False(t, layerCenter.Handle(ghgroupscontext.NewGhGroupsContext(nil)))
+		assert.Equal(t, int32(1), layers["bid_shading"].calls)
+	})
+
+	t.Run("Input=sequential", func(t *testing.T) {
+		layerCenter, layers := newLayerCenter(t, ModeSequential, map[string]bool{"creative": false, "bid_shading": true})
+		assert.False(t, layerCenter.Handle(ghgroupscontext.NewGhGroupsContext(nil)))
+		assert.Equal(t, int32(0), layers["bid_shading"].calls)
+	})
+
+	t.Run("Input=invalid_mode", func(t *testing.T) {
+		layerCenter := NewLayerCenter(utils.BuildConstructor(""))
+		err := layerCenter.LoadConfigFromMemory([]byte("name: parallel_center\nmode: random\n"))
+		assert.ErrorContains(t, err, "layer center parallel_center mode random is invalid")
+	})
+}
+
+func TestHoldout(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data", "holdout")
+	assert.Nil(t, errGetWd)
+
+	constructor := utils.BuildConstructor(testDataPath)
+	constructor.Register(reflect.TypeOf(sampledivider.SampleAutoConstructDivider{}))
+	constructor.Register(reflect.TypeOf(samplehandler.SampleAutoConstructHandler{}))
+	constructor.Register(reflect.TypeOf(layer.Layer{}))
+
+	invalidCases := map[string]string{
+		"Input=holdout_no_control.yaml":    "layer center holdout_no_control holdout has no control for layer layer_holdout_b",
+		"Input=holdout_bad_control.yaml":   "layer center holdout_bad_control holdout control handler_holdout_missing is not in handlers of layer layer_holdout_b",
+		"Input=holdout_unknown_layer.yaml": "layer center holdout_unknown_layer holdout has control for unknown layer layer_holdout_c",
+		"Input=holdout_percentage.yaml":    "layer center holdout_percentage holdout percentage 100 must be in (0, 100)",
+		"Input=holdout_no_unit_id.yaml":    "layer center holdout_no_unit_id holdout must have unit_id",
+	}
+	for name, expected := range invalidCases {
+		t.Run(name, func(t *testing.T) {
+			testName := t.Name()
+			comma := strings.Index(testName, "=")
+			assert.Greater(t, comma, 0)
+			confName := testName[comma+1:]
+			confPath := path.Join(testDataPath, confName)
+			assert.FileExists(t, confPath)
+
+			layerCenter := NewLayerCenter(constructor)
+			err := layerCenter.LoadConfigFromFile(confPath)
+			assert.ErrorContains(t, err, expected)
+		})
+	}
+
+	t.Run("Input=holdout_valid.yaml", func(t *testing.T) {
+		layerCenter := NewLayerCenter(constructor)
+		err := layerCenter.LoadConfigFromFile(path.Join(testDataPath, "holdout_valid.yaml"))
+		assert.Nil(t, err)
+
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		assert.True(t, layerCenter.Handle(ctx))
+		assert.False(t, ctx.InHoldout())
+
+		holdouts := 0
+		collector := outcome.NewCollector()
+		for i := 0; i < 2000; i++ {
+			unitID := fmt.Sprint("user_", i)
+			ctx := ghgroupscontext.NewGhGroupsContext(nil)
+			ctx.SetAttribute("user_id", unitID)
+			assert.True(t, layerCenter.Handle(ctx))
+			collector.Record(ctx)
+
+			exposures := ctx.Exposures()
+			assert.Len(t, exposures, 3)
+			if bucket.Bucket(unitID, "holdout_valid.holdout", 10000) < 1000 {
+				holdouts++
+				assert.True(t, ctx.InHoldout())
+				assert.Equal(t, []ghgroupscontext.Exposure{
+					{Layer: "holdout_valid", Handler: HoldoutGroup, Reason: HoldoutGroup},
+					{Layer: "layer_holdout_a", Handler: "handler_holdout_control", Forced: true, Reason: layer.ReasonHoldout},
+					{Layer: "layer_holdout_b", Handler: "handler_holdout_control", Forced: true, Reason: layer.ReasonHoldout},
+				}, exposures)
+			} else {
+				assert.False(t, ctx.InHoldout())
+				assert.Equal(t, ghgroupscontext.Exposure{Layer: "holdout_valid", Handler: ExperimentGroup, Reason: HoldoutGroup}, exposures[0])
+				assert.Equal(t, "handler_holdout_treatment", exposures[1].Handler)
+				assert.Equal(t, "handler_holdout_treatment", exposures[2].Handler)
+			}
+		}
+		assert.InDelta(t, 200, holdouts, 60)
+
+		// holdout请求被强制到对照组，不计入Layer的实验统计
+		someInterface, err := constructor.GetConcrete("layer_holdout_a")
+		assert.Nil(t, err)
+		report := collector.Report(someInterface.(outcome.ControlledLayerInterface))
+		exposures := int64(0)
+		for _, variant := range report.Variants {
+			assert.NotEqual(t, "handler_holdout_control", variant.Handler)
+			exposures += variant.Exposures
+		}
+		assert.Equal(t, int64(2000-holdouts), exposures)
+	})
+}
@@ -0,0 +1,187 @@
+package outcome
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+)
+
+// 按Layer和分支汇总handler上报的结果指标，用于在服务进程内直接判断实验效果
+// 每个请求处理完成后调用Record，请求中由divider选择的每个Exposure计入一次曝光，请求上报的每个Metric都计入该分支
+// 白名单、调试覆盖、holdout等强制选择（Exposure.Forced）不是随机分配的流量，不计入统计
+// Report给出各分支每个指标的样本数、均值、95%置信区间，以及与Layer配置的对照组的Welch t检验
+
+type Collector struct {
+	mutex  sync.RWMutex
+	layers map[string]map[string]*variantStats
+}
+
+type variantStats struct {
+	exposures int64
+	metrics   map[string]*welford
+}
+
+func NewCollector() *Collector {
+	return &Collector{
+		layers: make(map[string]map[string]*variantStats),
+	}
+}
+
+// Record 把一次请求的曝光和指标计入统计，需要在根组件Handle返回之后调用
+func (c *Collector) Record(ctx *ghgroupscontext.GhGroupsContext) {
+	exposures := ctx.Exposures()
+	if len(exposures) == 0 {
+		return
+	}
+	metrics := ctx.Metrics()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, exposure := range exposures {
+		if exposure.Forced {
+			continue
+		}
+		variants, ok := c.layers[exposure.Layer]
+		if !ok {
+			variants = make(map[string]*variantStats)
+			c.layers[exposure.Layer] = variants
+		}
+		variant, ok := variants[exposure.Handler]
+		if !ok {
+			variant = &variantStats{metrics: make(map[string]*welford)}
+			variants[exposure.Handler] = variant
+		}
+		variant.exposures++
+		for _, metric := range metrics {
+			stats, ok := variant.metrics[metric.Name]
+			if !ok {
+				stats = &welford{}
+				variant.metrics[metric.Name] = stats
+			}
+			stats.add(metric.Value)
+		}
+	}
+}
+
+// Reset 清空所有统计，例如实验调整分配之后重新开始观察
+func (c *Collector) Reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.layers = make(map[string]map[string]*variantStats)
+}
+
+// Layers 返回有统计数据的Layer名称
+func (c *Collector) Layers() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	layers := make([]string, 0, len(c.layers))
+	for layer := range c.layers {
+		layers = append(layers, layer)
+	}
+	sort.Strings(layers)
+	return layers
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+
+type LayerReport struct {
+	Layer    string
+	Control  string
+	Variants []VariantReport
+}
+
+type VariantReport struct {
+	Handler   string
+	Exposures int64
+	Metrics   []MetricReport
+}
+
+type MetricReport struct {
+	Name   string
+	Count  int64
+	Mean   float64
+	StdDev float64
+	Lower  float64
+	Upper  float64
+	// Comparison 是与对照组同名指标的比较，对照组自身或者样本不足时为nil
+	Comparison *Comparison
+}
+
+// Comparison 是Welch t检验的结果，Diff为该分支均值减去对照组均值
+type Comparison struct {
+	Diff   float64
+	T      float64
+	DF     float64
+	PValue float64
+}
+
+// ControlledLayerInterface 是带有对照组配置的Layer，*layer.Layer满足该接口
+type ControlledLayerInterface interface {
+	Name() string
+	Control() string
+}
+
+// Report 返回layer各分支的统计，以layer配置的对照组为基准比较，没有配置对照组或者对照组没有数据时不做比较
+func (c *Collector) Report(controlledLayer ControlledLayerInterface) LayerReport {
+	layer, control := controlledLayer.Name(), controlledLayer.Control()
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	report := LayerReport{Layer: layer, Control: control}
+	variants := c.layers[layer]
+	handlers := make([]string, 0, len(variants))
+	for handler := range variants {
+		handlers = append(handlers, handler)
+	}
+	sort.Strings(handlers)
+
+	controlStats := variants[control]
+	for _, handler := range handlers {
+		variant := variants[handler]
+		variantReport := VariantReport{Handler: handler, Exposures: variant.exposures}
+		names := make([]string, 0, len(variant.metrics))
+		for name := range variant.metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			stats := variant.metrics[name]
+			lower, upper := stats.confidenceInterval()
+			metricReport := MetricReport{
+				Name:   name,
+				Count:  stats.n,
+				Mean:   stats.mean,
+				StdDev: stats.stdDev(),
+				Lower:  lower,
+				Upper:  upper,
+			}
+			if controlStats != nil && handler != control {
+				if controlMetric, ok := controlStats.metrics[name]; ok {
+					metricReport.Comparison = welchTest(stats, controlMetric)
+				}
+			}
+			variantReport.Metrics = append(variantReport.Metrics, metricReport)
+		}
+		report.Variants = append(report.Variants, variantReport)
+	}
+	return report
+}
+
+// String 输出便于在管理接口中直接查看的文本
+func (r LayerReport) String() string {
+	builder := strings.Builder{}
+	fmt.Fprintf(&builder, "layer %s control %s\n", r.Layer, r.Control)
+	for _, variant := range r.Variants {
+		fmt.Fprintf(&builder, "\t%s exposures=%d\n", variant.Handler, variant.Exposures)
+		for _, metric := range variant.Metrics {
+			fmt.Fprintf(&builder, "\t\t%s n=%d mean=%.4g ci=[%.4g, %.4g]", metric.Name, metric.Count, metric.Mean, metric.Lower, metric.Upper)
+			if metric.Comparison != nil {
+				fmt.Fprintf(&builder, " diff=%.4g t=%.4g p=%.4g", metric.Comparison.Diff, metric.Comparison.T, metric.Comparison.PValue)
+			}
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
+}
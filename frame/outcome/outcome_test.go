@@ -0,0 +1,115 @@
+package outcome
+
+import (
+	"math"
+	"testing"
+
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testLayer struct {
+	name    string
+	control string
+}
+
+func (t *testLayer) Name() string {
+	return t.name
+}
+
+func (t *testLayer) Control() string {
+	return t.control
+}
+
+var layerA = &testLayer{name: "layer_a", control: "handler_control"}
+
+func record(collector *Collector, handler string, metrics map[string]float64) {
+	recordExposure(collector, ghgroupscontext.Exposure{Layer: "layer_a", Handler: handler}, metrics)
+}
+
+func recordExposure(collector *Collector, exposure ghgroupscontext.Exposure, metrics map[string]float64) {
+	ctx := ghgroupscontext.NewGhGroupsContext(nil)
+	ctx.Expose(exposure)
+	for name, value := range metrics {
+		ctx.ReportMetric(name, value)
+	}
+	collector.Record(ctx)
+}
+
+func TestReport(t *testing.T) {
+	collector := NewCollector()
+	for _, value := range []float64{1, 2, 3, 4, 5} {
+		record(collector, "handler_control", map[string]float64{"revenue": value})
+	}
+	for _, value := range []float64{2, 4, 6, 8, 10} {
+		record(collector, "handler_treatment", map[string]float64{"revenue": value, "won": 1})
+	}
+	collector.Record(ghgroupscontext.NewGhGroupsContext(nil))
+	assert.Equal(t, []string{"layer_a"}, collector.Layers())
+
+	report := collector.Report(layerA)
+	assert.Equal(t, "handler_control", report.Control)
+	assert.Len(t, report.Variants, 2)
+
+	control := report.Variants[0]
+	assert.Equal(t, "handler_control", control.Handler)
+	assert.Equal(t, int64(5), control.Exposures)
+	assert.Len(t, control.Metrics, 1)
+	assert.Equal(t, int64(5), control.Metrics[0].Count)
+	assert.InDelta(t, 3, control.Metrics[0].Mean, 1e-9)
+	assert.InDelta(t, math.Sqrt(2.5), control.Metrics[0].StdDev, 1e-9)
+	assert.InDelta(t, 3-z95*math.Sqrt(0.5), control.Metrics[0].Lower, 1e-9)
+	assert.Nil(t, control.Metrics[0].Comparison)
+
+	treatment := report.Variants[1]
+	assert.Equal(t, "revenue", treatment.Metrics[0].Name)
+	comparison := treatment.Metrics[0].Comparison
+	assert.NotNil(t, comparison)
+	assert.InDelta(t, 3, comparison.Diff, 1e-9)
+	assert.InDelta(t, 3/math.Sqrt(2.5), comparison.T, 1e-9)
+	assert.InDelta(t, 6.25/1.0625, comparison.DF, 1e-9)
+	assert.Greater(t, comparison.PValue, 0.05)
+	assert.Less(t, comparison.PValue, 0.2)
+	assert.Equal(t, "won", treatment.Metrics[1].Name)
+	assert.Nil(t, treatment.Metrics[1].Comparison)
+
+	assert.Contains(t, report.String(), "\thandler_treatment exposures=5\n\t\trevenue n=5 mean=6")
+
+	collector.Reset()
+	assert.Empty(t, collector.Report(layerA).Variants)
+}
+
+func TestForced(t *testing.T) {
+	collector := NewCollector()
+	for _, value := range []float64{1, 2, 3} {
+		record(collector, "handler_control", map[string]float64{"revenue": value})
+		record(collector, "handler_treatment", map[string]float64{"revenue": value * 2})
+	}
+	before := collector.Report(layerA)
+
+	// 白名单强制进入实验组的请求不是随机分配的流量，不能改变统计结果
+	recordExposure(collector, ghgroupscontext.Exposure{Layer: "layer_a", Handler: "handler_treatment", Forced: true, Reason: "override"}, map[string]float64{"revenue": 100})
+	recordExposure(collector, ghgroupscontext.Exposure{Layer: "layer_a", Handler: "handler_debug", Forced: true, Reason: "debug"}, map[string]float64{"revenue": 100})
+	assert.Equal(t, before, collector.Report(layerA))
+
+	// 没有配置对照组时不做比较
+	report := collector.Report(&testLayer{name: "layer_a"})
+	assert.Nil(t, report.Variants[1].Metrics[0].Comparison)
+}
+
+func TestPValue(t *testing.T) {
+	// t分布双侧5%的临界值
+	cases := []struct {
+		t  float64
+		df float64
+	}{
+		{12.706204736, 1},
+		{2.228138852, 10},
+		{1.983971519, 100},
+	}
+	for _, c := range cases {
+		assert.InDelta(t, 0.05, regularizedIncompleteBeta(c.df/(c.df+c.t*c.t), c.df/2, 0.5), 1e-6)
+	}
+	assert.InDelta(t, 1, regularizedIncompleteBeta(1, 5, 0.5), 1e-12)
+}
@@ -0,0 +1,125 @@
+package outcome
+
+import "math"
+
+// z值，正态近似的95%置信区间
+const z95 = 1.959963984540054
+
+// welford 在线计算均值和方差，数值上比累加平方和稳定
+type welford struct {
+	n    int64
+	mean float64
+	m2   float64
+}
+
+func (w *welford) add(value float64) {
+	w.n++
+	delta := value - w.mean
+	w.mean += delta / float64(w.n)
+	w.m2 += delta * (value - w.mean)
+}
+
+// variance 返回样本方差，样本数小于2时为0
+func (w *welford) variance() float64 {
+	if w.n < 2 {
+		return 0
+	}
+	return w.m2 / float64(w.n-1)
+}
+
+func (w *welford) stdDev() float64 {
+	return math.Sqrt(w.variance())
+}
+
+func (w *welford) confidenceInterval() (float64, float64) {
+	if w.n == 0 {
+		return 0, 0
+	}
+	margin := z95 * math.Sqrt(w.variance()/float64(w.n))
+	return w.mean - margin, w.mean + margin
+}
+
+// welchTest 对两个方差不一定相等的样本做双侧t检验，任意一边样本数小于2时返回nil
+func welchTest(a *welford, b *welford) *Comparison {
+	if a.n < 2 || b.n < 2 {
+		return nil
+	}
+	va := a.variance() / float64(a.n)
+	vb := b.variance() / float64(b.n)
+	diff := a.mean - b.mean
+	if va+vb == 0 {
+		pValue := 1.0
+		if diff != 0 {
+			pValue = 0
+		}
+		return &Comparison{Diff: diff, T: math.Copysign(math.Inf(1), diff), DF: float64(a.n + b.n - 2), PValue: pValue}
+	}
+	t := diff / math.Sqrt(va+vb)
+	df := (va + vb) * (va + vb) / (va*va/float64(a.n-1) + vb*vb/float64(b.n-1))
+	// 双侧p值 = I_{df/(df+t^2)}(df/2, 1/2)
+	pValue := regularizedIncompleteBeta(df/(df+t*t), df/2, 0.5)
+	return &Comparison{Diff: diff, T: t, DF: df, PValue: pValue}
+}
+
+// regularizedIncompleteBeta 使用连分式计算I_x(a, b)
+func regularizedIncompleteBeta(x float64, a float64, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lgab, _ := math.Lgamma(a + b)
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+func betaContinuedFraction(x float64, a float64, b float64) float64 {
+	const maxIterations = 300
+	const epsilon = 1e-14
+	const tiny = 1e-300
+
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	result := d
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+		numerator := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		result *= d * c
+
+		numerator = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + numerator*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + numerator/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		result *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,248 @@
+package ruledivider
+
+import (
+	"fmt"
+	"ghgroups/frame"
+	"regexp"
+	"strconv"
+	"strings"
+
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 按请求属性匹配规则选择handler的divider
+// rules按顺序匹配，第一个所有条件都满足的规则决定选择的handler，都不满足时选择default
+// 每个条件只能使用equal、in、range、prefix、regex中的一种，规则在加载配置时编译
+// 命中的规则下标会记录在追踪信息中，命中default时记录为-1
+
+type RuleDividerConf struct {
+	Type    string     `yaml:"type"`
+	Name    string     `yaml:"name"`
+	Default string     `yaml:"default"`
+	Rules   []RuleConf `yaml:"rules"`
+}
+
+type RuleConf struct {
+	Handler string          `yaml:"handler"`
+	Match   []ConditionConf `yaml:"match"`
+}
+
+type ConditionConf struct {
+	Attribute string     `yaml:"attribute"`
+	Equal     any        `yaml:"equal"`
+	In        []any      `yaml:"in"`
+	Range     *RangeConf `yaml:"range"`
+	Prefix    string     `yaml:"prefix"`
+	Regex     string     `yaml:"regex"`
+}
+
+// RangeConf 表示左闭右开的数值区间，min和max都可以省略
+type RangeConf struct {
+	Min *float64 `yaml:"min"`
+	Max *float64 `yaml:"max"`
+}
+
+type RuleDivider struct {
+	frame.DividerBaseInterface
+	frame.LoadConfigFromMemoryInterface
+	conf  RuleDividerConf
+	rules []rule
+}
+
+type rule struct {
+	handler    string
+	conditions []condition
+}
+
+type condition struct {
+	attribute string
+	match     func(value any) bool
+}
+
+func NewRuleDivider() *RuleDivider {
+	return &RuleDivider{}
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// LoadConfigFromMemoryInterface
+func (r *RuleDivider) LoadConfigFromMemory(configure []byte) error {
+	conf := new(RuleDividerConf)
+	err := yaml.Unmarshal([]byte(configure), conf)
+	if err != nil {
+		return err
+	}
+	if conf.Default == "" {
+		return fmt.Errorf("rule divider %s must have default", conf.Name)
+	}
+
+	rules := make([]rule, 0, len(conf.Rules))
+	for i, ruleConf := range conf.Rules {
+		compiled, err := compileRule(ruleConf)
+		if err != nil {
+			return fmt.Errorf("rule divider %s rule %d: %v", conf.Name, i, err)
+		}
+		rules = append(rules, compiled)
+	}
+
+	r.conf = *conf
+	r.rules = rules
+	return nil
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// ConcreteInterface
+func (r *RuleDivider) Name() string {
+	return r.conf.Name
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// DividerBaseInterface
+func (r *RuleDivider) Select(context *ghgroupscontext.GhGroupsContext) string {
+	index, handler := r.match(context)
+	context.Trace(r.conf.Name+".rule_index", index)
+	return handler
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// DividerOutputsInterface
+func (r *RuleDivider) Outputs() []string {
+	outputs := []string{r.conf.Default}
+	for _, rule := range r.rules {
+		outputs = append(outputs, rule.handler)
+	}
+	return outputs
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+
+func (r *RuleDivider) match(context *ghgroupscontext.GhGroupsContext) (int, string) {
+	for i, rule := range r.rules {
+		if rule.match(context) {
+			return i, rule.handler
+		}
+	}
+	return -1, r.conf.Default
+}
+
+func (r *rule) match(context *ghgroupscontext.GhGroupsContext) bool {
+	for _, condition := range r.conditions {
+		value, ok := context.GetAttribute(condition.attribute)
+		if !ok || !condition.match(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func compileRule(ruleConf RuleConf) (rule, error) {
+	if ruleConf.Handler == "" {
+		return rule{}, fmt.Errorf("handler is empty")
+	}
+	if len(ruleConf.Match) == 0 {
+		return rule{}, fmt.Errorf("match is empty for %s", ruleConf.Handler)
+	}
+	conditions := make([]condition, 0, len(ruleConf.Match))
+	for _, conditionConf := range ruleConf.Match {
+		compiled, err := compileCondition(conditionConf)
+		if err != nil {
+			return rule{}, err
+		}
+		conditions = append(conditions, compiled)
+	}
+	return rule{handler: ruleConf.Handler, conditions: conditions}, nil
+}
+
+func compileCondition(conditionConf ConditionConf) (condition, error) {
+	if conditionConf.Attribute == "" {
+		return condition{}, fmt.Errorf("condition attribute is empty")
+	}
+
+	var matchers []func(value any) bool
+	if conditionConf.Equal != nil {
+		expected := fmt.Sprint(conditionConf.Equal)
+		matchers = append(matchers, func(value any) bool {
+			return fmt.Sprint(value) == expected
+		})
+	}
+	if conditionConf.In != nil {
+		set := make(map[string]struct{}, len(conditionConf.In))
+		for _, v := range conditionConf.In {
+			set[fmt.Sprint(v)] = struct{}{}
+		}
+		matchers = append(matchers, func(value any) bool {
+			_, ok := set[fmt.Sprint(value)]
+			return ok
+		})
+	}
+	if conditionConf.Range != nil {
+		min, max := conditionConf.Range.Min, conditionConf.Range.Max
+		if min == nil && max == nil {
+			return condition{}, fmt.Errorf("range of %s must have min or max", conditionConf.Attribute)
+		}
+		if min != nil && max != nil && *min >= *max {
+			return condition{}, fmt.Errorf("range of %s is empty [%v, %v)", conditionConf.Attribute, *min, *max)
+		}
+		matchers = append(matchers, func(value any) bool {
+			number, ok := toFloat64(value)
+			if !ok {
+				return false
+			}
+			return (min == nil || number >= *min) && (max == nil || number < *max)
+		})
+	}
+	if conditionConf.Prefix != "" {
+		prefix := conditionConf.Prefix
+		matchers = append(matchers, func(value any) bool {
+			return strings.HasPrefix(fmt.Sprint(value), prefix)
+		})
+	}
+	if conditionConf.Regex != "" {
+		re, err := regexp.Compile(conditionConf.Regex)
+		if err != nil {
+			return condition{}, fmt.Errorf("regex of %s compile error: %v", conditionConf.Attribute, err)
+		}
+		matchers = append(matchers, func(value any) bool {
+			return re.MatchString(fmt.Sprint(value))
+		})
+	}
+
+	if len(matchers) != 1 {
+		return condition{}, fmt.Errorf("condition of %s must have exactly one of equal, in, range, prefix, regex", conditionConf.Attribute)
+	}
+	return condition{attribute: conditionConf.Attribute, match: matchers[0]}, nil
+}
+
+func toFloat64(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		number, err := strconv.ParseFloat(v, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
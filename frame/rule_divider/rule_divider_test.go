@@ -0,0 +1,125 @@
+package ruledivider
+
+import (
+	"ghgroups/frame"
+	"ghgroups/frame/layer"
+	"ghgroups/frame/utils"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+	samplehandler "ghgroups/frame/sample_handler"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfigFromMemory(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data")
+	assert.Nil(t, errGetWd)
+
+	cases := map[string]string{
+		"Input=valid/rule_divider_a.yaml":  "",
+		"Input=invalid/no_default.yaml":    "rule divider no_default must have default",
+		"Input=invalid/two_operators.yaml": "must have exactly one of equal, in, range, prefix, regex",
+		"Input=invalid/error_regex.yaml":   "regex of device compile error",
+		"Input=invalid/empty_range.yaml":   "range of age is empty",
+	}
+	for name, expected := range cases {
+		expected := expected
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			testName := t.Name()
+			comma := strings.Index(testName, "=")
+			assert.Greater(t, comma, 0)
+			confName := testName[comma+1:]
+			confPath := path.Join(testDataPath, confName)
+			data, err := os.ReadFile(confPath)
+			if err == nil {
+				divider := NewRuleDivider()
+				err = divider.LoadConfigFromMemory(data)
+			}
+			if expected == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.ErrorContains(t, err, expected)
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data")
+	assert.Nil(t, errGetWd)
+
+	data, err := os.ReadFile(path.Join(testDataPath, "valid", "rule_divider_a.yaml"))
+	assert.Nil(t, err)
+	divider := NewRuleDivider()
+	assert.Nil(t, divider.LoadConfigFromMemory(data))
+	assert.Equal(t, []string{"handler_default", "handler_ios_us", "handler_adult", "handler_banner", "handler_chrome"}, divider.Outputs())
+
+	cases := []struct {
+		name       string
+		attributes map[string]any
+		expected   string
+		index      int
+	}{
+		{"Input=Equal&In", map[string]any{"device": "ios", "region": "us-east-2"}, "handler_ios_us", 0},
+		{"Input=EqualWithoutIn", map[string]any{"device": "ios", "region": "eu-west-1"}, "handler_default", -1},
+		{"Input=RangeInt", map[string]any{"age": 18}, "handler_adult", 1},
+		{"Input=RangeUpperBound", map[string]any{"age": int64(65)}, "handler_default", -1},
+		{"Input=RangeString", map[string]any{"age": "30.5"}, "handler_adult", 1},
+		{"Input=Prefix", map[string]any{"placement": "banner_top"}, "handler_banner", 2},
+		{"Input=Regex", map[string]any{"user_agent": "Mozilla/5.0 Chrome/118 Safari"}, "handler_chrome", 3},
+		{"Input=Order", map[string]any{"age": 20, "placement": "banner_top"}, "handler_adult", 1},
+		{"Input=Empty", map[string]any{}, "handler_default", -1},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := ghgroupscontext.NewGhGroupsContext(nil)
+			ctx.EnableTrace()
+			for k, v := range c.attributes {
+				ctx.SetAttribute(k, v)
+			}
+			assert.Equal(t, c.expected, divider.Select(ctx))
+			index, ok := ctx.TraceRoot().Get("rule_divider_a.rule_index")
+			assert.True(t, ok)
+			assert.Equal(t, c.index, index)
+		})
+	}
+}
+
+func TestInLayer(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data", "layer")
+	assert.Nil(t, errGetWd)
+
+	constructor := utils.BuildConstructor(testDataPath)
+	constructor.Register(reflect.TypeOf(RuleDivider{}))
+	constructor.Register(reflect.TypeOf(samplehandler.SampleAutoConstructHandler{}))
+	constructor.Register(reflect.TypeOf(layer.Layer{}))
+
+	err := constructor.CreateConcrete("layer_rule")
+	assert.Nil(t, err)
+	someInterface, err := constructor.GetConcrete("layer_rule")
+	assert.Nil(t, err)
+	layerInterface, ok := someInterface.(frame.LayerBaseInterface)
+	assert.True(t, ok)
+
+	ctx := ghgroupscontext.NewGhGroupsContext(nil)
+	ctx.EnableTrace()
+	ctx.SetAttribute("device", "android")
+	assert.True(t, layerInterface.Handle(ctx))
+
+	index, ok := ctx.TraceRoot().Get("rule_divider_layer.rule_index")
+	assert.True(t, ok)
+	assert.Equal(t, 0, index)
+	assert.NotNil(t, ctx.TraceRoot().Find("handler_sample_b"))
+	assert.Nil(t, ctx.TraceRoot().Find("handler_sample_a"))
+}
@@ -0,0 +1,38 @@
+package frame
+
+import "fmt"
+
+// Scope 组件实例的作用域，在组件的配置文件中通过scope字段设置
+type Scope string
+
+const (
+	// ScopeSingleton 默认作用域，所有引用处共享同一个实例
+	ScopeSingleton Scope = "singleton"
+	// ScopePerReference 每个引用处各自持有一个实例
+	ScopePerReference Scope = "per_reference"
+	// ScopePerRequest 每次执行时从池中取出实例，执行结束后重置并放回
+	ScopePerRequest Scope = "per_request"
+)
+
+// ParseScope 解析配置中的scope字段，未设置时返回ScopeSingleton
+func ParseScope(value any) (Scope, error) {
+	if value == nil {
+		return ScopeSingleton, nil
+	}
+	scope, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("scope %v is not a string", value)
+	}
+	switch Scope(scope) {
+	case "":
+		return ScopeSingleton, nil
+	case ScopeSingleton, ScopePerReference, ScopePerRequest:
+		return Scope(scope), nil
+	}
+	return "", fmt.Errorf("unknown scope %s, must be one of %s, %s, %s", scope, ScopeSingleton, ScopePerReference, ScopePerRequest)
+}
+
+// ResetInterface 是可选接口，per_request的组件放回池之前会调用Reset清理本次请求留下的状态
+type ResetInterface interface {
+	Reset()
+}
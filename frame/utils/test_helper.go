@@ -20,5 +20,5 @@ func BuildConstructor(concretePath string) *constructor.Constructor {
 	factory.Register(reflect.TypeOf(layercenterconstructor.LayerCenterConstructor{}))
 	factory.Register(reflect.TypeOf(handlergroupconstructor.HandlerGroupConstructor{}))
 	factory.Register(reflect.TypeOf(aynchandlergroupconstructor.AsyncHandlerGroupConstructor{}))
-	return constructor.NewConstructor(factory, concretePath)
+	return constructor.MustNewConstructor(factory, concretePath)
 }
@@ -0,0 +1,218 @@
+package weighteddivider
+
+import (
+	"fmt"
+	"ghgroups/frame"
+	"ghgroups/frame/allocation"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 按权重随机选择handler的divider，只需要配置文件即可在任意Layer中使用
+// weights中key是handler名称，value是整数权重，权重为0的handler不会被选中
+// 配置在加载时被编译成有序的累积权重表，Select过程中只读，不需要加锁
+// UpdateWeights 在运行时校验并原子替换整张权重表，每次生效的权重都记录在History中
+
+type WeightedDividerConf struct {
+	Type    string         `yaml:"type"`
+	Name    string         `yaml:"name"`
+	Weights map[string]int `yaml:"weights"`
+}
+
+// RandSource 是随机数来源，*rand.Rand 满足该接口，测试中可以注入固定种子的实现
+// Select会被并发调用，*rand.Rand本身不是并发安全的，只适合在测试中单协程使用
+type RandSource interface {
+	Int63n(n int64) int64
+}
+
+type WeightedDivider struct {
+	frame.DividerBaseInterface
+	frame.LoadConfigFromMemoryInterface
+	conf        WeightedDividerConf
+	table       atomic.Pointer[weightTable]
+	randSource  atomic.Pointer[RandSource]
+	updateMutex sync.Mutex
+	bindings    allocation.Bindings
+	history     allocation.History[map[string]int]
+}
+
+type weightTable struct {
+	weights map[string]int
+	names   []string
+	bounds  []int64
+	total   int64
+}
+
+func NewWeightedDivider() *WeightedDivider {
+	w := &WeightedDivider{}
+	w.SetRandSource(newPoolRandSource())
+	return w
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// LoadConfigFromMemoryInterface
+func (w *WeightedDivider) LoadConfigFromMemory(configure []byte) error {
+	conf := new(WeightedDividerConf)
+	err := yaml.Unmarshal([]byte(configure), conf)
+	if err != nil {
+		return err
+	}
+	table, err := newWeightTable(conf.Name, conf.Weights)
+	if err != nil {
+		return err
+	}
+
+	w.conf = *conf
+	w.table.Store(table)
+	w.history.Append(table.copyWeights())
+	var randSource RandSource = newPoolRandSource()
+	w.randSource.CompareAndSwap(nil, &randSource)
+	return nil
+}
+
+func newWeightTable(dividerName string, weights map[string]int) (*weightTable, error) {
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("weighted divider %s has no weights", dividerName)
+	}
+
+	table := &weightTable{weights: make(map[string]int, len(weights))}
+	for name, weight := range weights {
+		table.weights[name] = weight
+		table.names = append(table.names, name)
+	}
+	sort.Strings(table.names)
+
+	table.bounds = make([]int64, len(table.names))
+	for i, name := range table.names {
+		weight := weights[name]
+		if weight < 0 {
+			return nil, fmt.Errorf("weighted divider %s has negative weight %d for %s", dividerName, weight, name)
+		}
+		table.total += int64(weight)
+		table.bounds[i] = table.total
+	}
+	if table.total <= 0 {
+		return nil, fmt.Errorf("weighted divider %s total weight must be positive", dividerName)
+	}
+	return table, nil
+}
+
+func (t *weightTable) copyWeights() map[string]int {
+	weights := make(map[string]int, len(t.weights))
+	for name, weight := range t.weights {
+		weights[name] = weight
+	}
+	return weights
+}
+
+func (t *weightTable) outputs() []string {
+	outputs := make([]string, 0, len(t.names))
+	for _, name := range t.names {
+		if t.weights[name] > 0 {
+			outputs = append(outputs, name)
+		}
+	}
+	return outputs
+}
+
+// UpdateWeights 校验后原子替换权重，权重为正的handler必须存在于所有使用该divider的Layer中
+func (w *WeightedDivider) UpdateWeights(weights map[string]int) error {
+	w.updateMutex.Lock()
+	defer w.updateMutex.Unlock()
+	table, err := newWeightTable(w.conf.Name, weights)
+	if err != nil {
+		return err
+	}
+	if err := w.bindings.Check(w.conf.Name, table.outputs()); err != nil {
+		return err
+	}
+	w.table.Store(table)
+	w.history.Append(table.copyWeights())
+	return nil
+}
+
+// Weights 返回当前生效的权重
+func (w *WeightedDivider) Weights() map[string]int {
+	table := w.table.Load()
+	if table == nil {
+		return nil
+	}
+	return table.copyWeights()
+}
+
+// History 返回加载配置以及每次UpdateWeights生效的权重和时间
+func (w *WeightedDivider) History() []allocation.Change[map[string]int] {
+	return w.history.Changes()
+}
+
+// SetRandSource 原子替换随机数来源，可以与Select并发调用
+func (w *WeightedDivider) SetRandSource(randSource RandSource) {
+	w.randSource.Store(&randSource)
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// ConcreteInterface
+func (w *WeightedDivider) Name() string {
+	return w.conf.Name
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// DividerBaseInterface
+func (w *WeightedDivider) Select(context *ghgroupscontext.GhGroupsContext) string {
+	table := w.table.Load()
+	if table == nil {
+		return ""
+	}
+	point := (*w.randSource.Load()).Int63n(table.total)
+	index := sort.Search(len(table.bounds), func(i int) bool {
+		return table.bounds[i] > point
+	})
+	return table.names[index]
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// DividerOutputsInterface
+func (w *WeightedDivider) Outputs() []string {
+	table := w.table.Load()
+	if table == nil {
+		return nil
+	}
+	return table.outputs()
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+// DividerBinderInterface
+func (w *WeightedDivider) BindLayer(layer string, handlers []string) error {
+	w.bindings.Bind(layer, handlers)
+	return nil
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////
+
+// poolRandSource 为每个P缓存一个*rand.Rand，避免使用全局加锁的随机数来源
+type poolRandSource struct {
+	pool sync.Pool
+}
+
+func newPoolRandSource() *poolRandSource {
+	seed := time.Now().UnixNano()
+	source := &poolRandSource{}
+	source.pool.New = func() any {
+		return rand.New(rand.NewSource(atomic.AddInt64(&seed, 1)))
+	}
+	return source
+}
+
+func (p *poolRandSource) Int63n(n int64) int64 {
+	r := p.pool.Get().(*rand.Rand)
+	value := r.Int63n(n)
+	p.pool.Put(r)
+	return value
+}
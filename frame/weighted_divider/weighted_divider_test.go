@@ -0,0 +1,258 @@
+package weighteddivider
+
+import (
+	"ghgroups/frame"
+	"ghgroups/frame/layer"
+	"ghgroups/frame/utils"
+	"math/rand"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	ghgroupscontext "ghgroups/frame/ghgroups_context"
+	samplehandler "ghgroups/frame/sample_handler"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedRandSource struct {
+	values []int64
+	index  int
+}
+
+func (f *fixedRandSource) Int63n(n int64) int64 {
+	value := f.values[f.index%len(f.values)] % n
+	f.index++
+	return value
+}
+
+func TestLoadConfigFromMemory(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data")
+	assert.Nil(t, errGetWd)
+
+	t.Run("Input=valid/weighted_divider_a.yaml", func(t *testing.T) {
+		t.Parallel()
+		testName := t.Name()
+		comma := strings.Index(testName, "=")
+		assert.Greater(t, comma, 0)
+		confName := testName[comma+1:]
+		confPath := path.Join(testDataPath, confName)
+		data, err := os.ReadFile(confPath)
+		assert.Nil(t, err)
+
+		divider := NewWeightedDivider()
+		err = divider.LoadConfigFromMemory(data)
+		assert.Nil(t, err)
+		assert.Equal(t, "weighted_divider_a", divider.Name())
+		assert.Equal(t, []string{"handler_sample_a", "handler_sample_b"}, divider.Outputs())
+	})
+
+	t.Run("Input=invalid/negative_weight.yaml", func(t *testing.T) {
+		t.Parallel()
+		testName := t.Name()
+		comma := strings.Index(testName, "=")
+		assert.Greater(t, comma, 0)
+		confName := testName[comma+1:]
+		confPath := path.Join(testDataPath, confName)
+		data, err := os.ReadFile(confPath)
+		assert.Nil(t, err)
+
+		divider := NewWeightedDivider()
+		err = divider.LoadConfigFromMemory(data)
+		assert.ErrorContains(t, err, "negative weight -1 for handler_sample_a")
+	})
+
+	t.Run("Input=invalid/zero_weight.yaml", func(t *testing.T) {
+		t.Parallel()
+		testName := t.Name()
+		comma := strings.Index(testName, "=")
+		assert.Greater(t, comma, 0)
+		confName := testName[comma+1:]
+		confPath := path.Join(testDataPath, confName)
+		data, err := os.ReadFile(confPath)
+		assert.Nil(t, err)
+
+		divider := NewWeightedDivider()
+		err = divider.LoadConfigFromMemory(data)
+		assert.ErrorContains(t, err, "total weight must be positive")
+	})
+
+	t.Run("Input=invalid/no_weights.yaml", func(t *testing.T) {
+		t.Parallel()
+		testName := t.Name()
+		comma := strings.Index(testName, "=")
+		assert.Greater(t, comma, 0)
+		confName := testName[comma+1:]
+		confPath := path.Join(testDataPath, confName)
+		data, err := os.ReadFile(confPath)
+		assert.Nil(t, err)
+
+		divider := NewWeightedDivider()
+		err = divider.LoadConfigFromMemory(data)
+		assert.ErrorContains(t, err, "has no weights")
+	})
+}
+
+func TestSelect(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data")
+	assert.Nil(t, errGetWd)
+
+	data, err := os.ReadFile(path.Join(testDataPath, "valid", "weighted_divider_a.yaml"))
+	assert.Nil(t, err)
+
+	t.Run("Input=FixedRandSource", func(t *testing.T) {
+		divider := NewWeightedDivider()
+		err := divider.LoadConfigFromMemory(data)
+		assert.Nil(t, err)
+		divider.SetRandSource(&fixedRandSource{values: []int64{0, 1, 2, 3}})
+
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		assert.Equal(t, "handler_sample_a", divider.Select(ctx))
+		assert.Equal(t, "handler_sample_b", divider.Select(ctx))
+		assert.Equal(t, "handler_sample_b", divider.Select(ctx))
+		assert.Equal(t, "handler_sample_b", divider.Select(ctx))
+	})
+
+	t.Run("Input=SeededRandSource", func(t *testing.T) {
+		first := NewWeightedDivider()
+		assert.Nil(t, first.LoadConfigFromMemory(data))
+		first.SetRandSource(rand.New(rand.NewSource(7)))
+
+		second := NewWeightedDivider()
+		assert.Nil(t, second.LoadConfigFromMemory(data))
+		second.SetRandSource(rand.New(rand.NewSource(7)))
+
+		ctx := ghgroupscontext.NewGhGroupsContext(nil)
+		counts := make(map[string]int)
+		for i := 0; i < 4000; i++ {
+			selected := first.Select(ctx)
+			assert.Equal(t, selected, second.Select(ctx))
+			counts[selected]++
+		}
+		assert.Equal(t, 0, counts["handler_sample_c"])
+		assert.InDelta(t, 3.0, float64(counts["handler_sample_b"])/float64(counts["handler_sample_a"]), 0.5)
+	})
+
+	t.Run("Input=Concurrent", func(t *testing.T) {
+		divider := NewWeightedDivider()
+		assert.Nil(t, divider.LoadConfigFromMemory(data))
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx := ghgroupscontext.NewGhGroupsContext(nil)
+				for j := 0; j < 1000; j++ {
+					selected := divider.Select(ctx)
+					assert.NotEqual(t, "handler_sample_c", selected)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestInLayer(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data", "layer")
+	assert.Nil(t, errGetWd)
+
+	constructor := utils.BuildConstructor(testDataPath)
+	constructor.Register(reflect.TypeOf(WeightedDivider{}))
+	constructor.Register(reflect.TypeOf(samplehandler.SampleAutoConstructHandler{}))
+	constructor.Register(reflect.TypeOf(layer.Layer{}))
+
+	err := constructor.CreateConcrete("layer_weighted")
+	assert.Nil(t, err)
+
+	someInterface, err := constructor.GetConcrete("weighted_divider_layer")
+	assert.Nil(t, err)
+	divider, ok := someInterface.(*WeightedDivider)
+	assert.True(t, ok)
+	divider.SetRandSource(&fixedRandSource{values: []int64{99}})
+
+	someInterface, err = constructor.GetConcrete("layer_weighted")
+	assert.Nil(t, err)
+	layerInterface, ok := someInterface.(frame.LayerBaseInterface)
+	assert.True(t, ok)
+
+	ctx := ghgroupscontext.NewGhGroupsContext(nil)
+	assert.Equal(t, "handler_sample_b", divider.Select(ctx))
+	assert.True(t, layerInterface.Handle(ctx))
+}
+
+func TestUpdateWeights(t *testing.T) {
+	runPath, errGetWd := os.Getwd()
+	testDataPath := path.Join(runPath, "test_data", "layer")
+	assert.Nil(t, errGetWd)
+
+	constructor := utils.BuildConstructor(testDataPath)
+	constructor.Register(reflect.TypeOf(WeightedDivider{}))
+	constructor.Register(reflect.TypeOf(samplehandler.SampleAutoConstructHandler{}))
+	constructor.Register(reflect.TypeOf(layer.Layer{}))
+
+	err := constructor.CreateConcrete("layer_weighted")
+	assert.Nil(t, err)
+	someInterface, err := constructor.GetConcrete("weighted_divider_layer")
+	assert.Nil(t, err)
+	divider, ok := someInterface.(*WeightedDivider)
+	assert.True(t, ok)
+	divider.SetRandSource(&fixedRandSource{values: []int64{0}})
+	ctx := ghgroupscontext.NewGhGroupsContext(nil)
+	assert.Equal(t, "handler_sample_a", divider.Select(ctx))
+
+	t.Run("Input=invalid", func(t *testing.T) {
+		err := divider.UpdateWeights(map[string]int{"handler_sample_a": 1, "handler_sample_c": 1})
+		assert.EqualError(t, err, "divider weighted_divider_layer target handler_sample_c is not in handlers of layer layer_weighted")
+		err = divider.UpdateWeights(map[string]int{"handler_sample_a": -1, "handler_sample_b": 2})
+		assert.ErrorContains(t, err, "negative weight")
+		err = divider.UpdateWeights(map[string]int{"handler_sample_a": 0})
+		assert.ErrorContains(t, err, "total weight must be positive")
+		assert.Len(t, divider.History(), 1)
+	})
+
+	t.Run("Input=valid", func(t *testing.T) {
+		initial := divider.Weights()
+		assert.Nil(t, divider.UpdateWeights(map[string]int{"handler_sample_a": 0, "handler_sample_b": 100}))
+		assert.Equal(t, "handler_sample_b", divider.Select(ctx))
+		assert.Equal(t, []string{"handler_sample_b"}, divider.Outputs())
+
+		history := divider.History()
+		assert.Len(t, history, 2)
+		assert.Equal(t, initial, history[0].Allocation)
+		assert.Equal(t, map[string]int{"handler_sample_a": 0, "handler_sample_b": 100}, history[1].Allocation)
+		assert.False(t, history[1].Time.Before(history[0].Time))
+	})
+	t.Run("Input=concurrent", func(t *testing.T) {
+		// 服务期间更新权重和替换随机数来源都不能与Select产生竞争
+		wg := sync.WaitGroup{}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx := ghgroupscontext.NewGhGroupsContext(nil)
+				for j := 0; j < 1000; j++ {
+					selected := divider.Select(ctx)
+					assert.Contains(t, []string{"handler_sample_a", "handler_sample_b"}, selected)
+				}
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.Nil(t, divider.UpdateWeights(map[string]int{"handler_sample_a": j % 3, "handler_sample_b": 1}))
+			}
+		}()
+		for j := 0; j < 100; j++ {
+			divider.SetRandSource(newPoolRandSource())
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,165 @@
+package ghgroups
+
+import (
+	"fmt"
+	"ghgroups/frame"
+	"ghgroups/frame/factory"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registration 描述默认注册表中的一个类型，Package是调用注册函数的包，便于排查类型由谁注册
+type Registration struct {
+	Type    string
+	Aliases []string
+	Package string
+}
+
+type registration struct {
+	Registration
+	concreteType reflect.Type
+	concreteFunc frame.ConcreteFunc
+}
+
+// Registry 在init阶段收集组件类型，构建Constructor时一次性注册到Factory
+type Registry struct {
+	mutex         sync.Mutex
+	registrations map[string]registration
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		registrations: make(map[string]registration),
+	}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default 返回各个包在init中注册时使用的默认注册表
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register 把组件类型T加入默认注册表，通常在包的init中调用
+func Register[T frame.ConcreteInterface](aliases ...string) error {
+	return register[T](3, aliases)
+}
+
+// MustRegister 与Register相同，失败时panic
+func MustRegister[T frame.ConcreteInterface](aliases ...string) {
+	if err := register[T](3, aliases); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterFunc 把以构造函数创建的类型加入默认注册表
+func RegisterFunc(concreteTypeName string, concreteFunc frame.ConcreteFunc, aliases ...string) error {
+	return registerFunc(3, concreteTypeName, concreteFunc, aliases)
+}
+
+// MustRegisterFunc 与RegisterFunc相同，失败时panic
+func MustRegisterFunc(concreteTypeName string, concreteFunc frame.ConcreteFunc, aliases ...string) {
+	if err := registerFunc(3, concreteTypeName, concreteFunc, aliases); err != nil {
+		panic(err)
+	}
+}
+
+// register 和registerFunc只能被导出的注册函数直接调用，skip跳过它们自身和导出函数，记录的是调用注册函数的包
+func register[T frame.ConcreteInterface](skip int, aliases []string) error {
+	concreteType := factory.TypeOf[T]()
+	return defaultRegistry.add(registration{
+		Registration: Registration{Type: factory.TypeName(concreteType), Aliases: aliases, Package: callerPackage(skip)},
+		concreteType: concreteType,
+	})
+}
+
+func registerFunc(skip int, concreteTypeName string, concreteFunc frame.ConcreteFunc, aliases []string) error {
+	return defaultRegistry.add(registration{
+		Registration: Registration{Type: concreteTypeName, Aliases: aliases, Package: callerPackage(skip)},
+		concreteFunc: concreteFunc,
+	})
+}
+
+// Registrations 按类型名称排序列出默认注册表中的所有类型
+func Registrations() []Registration {
+	return defaultRegistry.Registrations()
+}
+
+func (r *Registry) Registrations() []Registration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	registrations := make([]Registration, 0, len(r.registrations))
+	for _, registration := range r.registrations {
+		registration.Aliases = append([]string{}, registration.Aliases...)
+		registrations = append(registrations, registration.Registration)
+	}
+	sort.Slice(registrations, func(i, j int) bool {
+		return registrations[i].Type < registrations[j].Type
+	})
+	return registrations
+}
+
+// Apply 把注册表中的类型注册到factory，factory中已经注册过的类型会被跳过
+func (r *Registry) Apply(f *factory.Factory) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	names := make([]string, 0, len(r.registrations))
+	for name := range r.registrations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if f.Has(name) {
+			continue
+		}
+		registration := r.registrations[name]
+		var err error
+		if registration.concreteFunc != nil {
+			err = f.RegisterFunc(name, registration.concreteFunc, registration.Aliases...)
+		} else {
+			err = f.Register(registration.concreteType, registration.Aliases...)
+		}
+		if err != nil {
+			return fmt.Errorf("register %s from package %s error: %w", name, registration.Package, err)
+		}
+	}
+	return nil
+}
+
+func (r *Registry) add(registration registration) error {
+	if registration.Type == "" || (registration.concreteType == nil && registration.concreteFunc == nil) {
+		return &frame.BuildError{Kind: frame.ErrNotConcrete, Type: registration.Type}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if exist, ok := r.registrations[registration.Type]; ok {
+		return &frame.BuildError{Kind: frame.ErrDuplicateType, Type: registration.Type, Err: fmt.Errorf("already registered by package %s", exist.Package)}
+	}
+	r.registrations[registration.Type] = registration
+	return nil
+}
+
+// callerPackage 返回调用栈上第skip层函数所在的包路径
+func callerPackage(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip)
+	if !ok {
+		return ""
+	}
+	function := runtime.FuncForPC(pc)
+	if function == nil {
+		return ""
+	}
+	name := function.Name()
+	lastSlash := strings.LastIndex(name, "/")
+	if dot := strings.Index(name[lastSlash+1:], "."); dot >= 0 {
+		return name[:lastSlash+1+dot]
+	}
+	return name
+}
@@ -0,0 +1,68 @@
+package ghgroups
+
+import (
+	"errors"
+	"ghgroups/frame"
+	"ghgroups/frame/factory"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRegistryHandler struct{}
+
+func (t *testRegistryHandler) Name() string {
+	return "test_registry_handler"
+}
+
+func TestRegistry(t *testing.T) {
+	t.Run("Input=Register", func(t *testing.T) {
+		assert.Nil(t, Register[*testRegistryHandler]("registry_handler"))
+		err := Register[*testRegistryHandler]()
+		assert.True(t, errors.Is(err, frame.ErrDuplicateType))
+		assert.ErrorContains(t, err, "already registered by package ghgroups")
+
+		var found *Registration
+		for _, registration := range Registrations() {
+			if registration.Type == "ghgroups.testRegistryHandler" {
+				found = &registration
+				break
+			}
+		}
+		assert.NotNil(t, found)
+		assert.Equal(t, "ghgroups", found.Package)
+		assert.Equal(t, []string{"registry_handler"}, found.Aliases)
+	})
+
+	t.Run("Input=MustRegisterFunc", func(t *testing.T) {
+		concreteFunc := func([]byte, frame.ConstructorInterface) (frame.ConcreteInterface, error) {
+			return &testRegistryHandler{}, nil
+		}
+		MustRegisterFunc("ghgroups.testRegistryFunc", concreteFunc)
+		defer func() {
+			r := recover()
+			err, ok := r.(error)
+			assert.True(t, ok)
+			assert.True(t, errors.Is(err, frame.ErrDuplicateType))
+		}()
+		MustRegisterFunc("ghgroups.testRegistryFunc", concreteFunc)
+		assert.FailNow(t, "must panic when type is registered twice")
+	})
+
+	t.Run("Input=Apply", func(t *testing.T) {
+		registry := NewRegistry()
+		assert.Nil(t, registry.add(registration{
+			Registration: Registration{Type: factory.TypeName(factory.TypeOf[*testRegistryHandler]()), Package: "ghgroups"},
+			concreteType: factory.TypeOf[*testRegistryHandler](),
+		}))
+
+		f := factory.NewFactory()
+		assert.Nil(t, registry.Apply(f))
+		assert.True(t, f.Has("ghgroups.testRegistryHandler"))
+		// 已经手动注册过的类型会被跳过
+		assert.Nil(t, registry.Apply(f))
+
+		_, err := f.Create("testRegistryHandler", nil, nil)
+		assert.Nil(t, err)
+	})
+}